@@ -12,7 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+// Package browser manages the local Lightpanda browser: download, removal
+// and process creation.
+package browser
 
 import (
 	"context"
@@ -27,7 +29,8 @@ import (
 	"runtime"
 )
 
-func cleanup(_ context.Context) error {
+// Cleanup removes the downloaded browser.
+func Cleanup(_ context.Context) error {
 	// get the dir
 	dir, err := configdir()
 	if err != nil {
@@ -44,7 +47,8 @@ func cleanup(_ context.Context) error {
 	return nil
 }
 
-func download(ctx context.Context) error {
+// Download installs or updates the browser with the nightly build.
+func Download(ctx context.Context) error {
 	url, err := nightlyURL()
 	if err != nil {
 		return fmt.Errorf("get nightly url: %w", err)
@@ -149,8 +153,9 @@ func configdir() (string, error) {
 
 var ErrNoBrowser = errors.New("no browser")
 
-// runbrowser returns a command to run the local browser.
-func newbrowser(ctx context.Context) (*exec.Cmd, error) {
+// New returns a command to run the local browser.
+// It returns ErrNoBrowser if the browser isn't downloaded.
+func New(ctx context.Context) (*exec.Cmd, error) {
 	// get the dir
 	dir, err := configdir()
 	if err != nil {
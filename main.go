@@ -23,9 +23,15 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/chromedp/chromedp"
+
+	"github.com/lightpanda-io/gomcp/browser"
+	"github.com/lightpanda-io/gomcp/server"
 )
 
 const (
@@ -57,26 +63,72 @@ func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	flags.SetOutput(stderr)
 
 	var (
-		verbose = flags.Bool("verbose", false, "enable debug log level")
-		apiaddr = flags.String("api-addr", env("MCP_API_ADDRESS", ApiDefaultAddress), "http api server address")
-		cdp     = flags.String("cdp", os.Getenv("MCP_CDP"), "cdp ws to connect. By default gomcp will run the download Lightpanda browser.")
+		verbose   = flags.Bool("verbose", false, "enable debug log level")
+		apiaddr   = flags.String("api-addr", env("MCP_API_ADDRESS", ApiDefaultAddress), "http api server address")
+		cdp       = flags.String("cdp", os.Getenv("MCP_CDP"), "cdp ws to connect. By default gomcp will run the download Lightpanda browser.")
+		search    = flags.String("search", env("MCP_SEARCH", server.SearchDefault), "search engine used by the search tool: duckduckgo, bing, brave, searxng or template.")
+		searchurl = flags.String("search-url", os.Getenv("MCP_SEARCH_URL"), "searxng instance URL or search URL template with a {query} placeholder.")
+		prompts   = flags.String("prompts-dir", os.Getenv("MCP_PROMPTS_DIR"), "directory of JSON prompt files added to the builtin prompts.")
+		allow     = flags.String("allow", os.Getenv("MCP_ALLOW"), "comma separated host globs or CIDRs the browser is restricted to.")
+		deny      = flags.String("deny", os.Getenv("MCP_DENY"), "comma separated host globs or CIDRs the browser can't load.")
+		private   = flags.Bool("allow-private", envBool("MCP_ALLOW_PRIVATE", false), "allow the browser to load private and loopback addresses.")
+		keysfile  = flags.String("api-keys-file", os.Getenv("MCP_API_KEYS_FILE"), "file of the API keys hashes accepted by the HTTP API, one principal:sha256 per line.")
+		origins   = flags.String("cors-origins", os.Getenv("MCP_CORS_ORIGINS"), "comma separated origins allowed to call the HTTP API with credentials.")
+		resource  = flags.String("oauth-resource", os.Getenv("MCP_OAUTH_RESOURCE"), "canonical URL of the server, enables the OAuth access tokens validation.")
+		issuer    = flags.String("oauth-issuer", os.Getenv("MCP_OAUTH_ISSUER"), "issuer URL of the OAuth authorization server.")
+		audience  = flags.String("oauth-audience", os.Getenv("MCP_OAUTH_AUDIENCE"), "expected audience of the OAuth access tokens, the resource by default.")
+		jwks      = flags.String("oauth-jwks", os.Getenv("MCP_OAUTH_JWKS"), "path or URL of the authorization server's JSON web key set.")
+		scopes    = flags.String("oauth-scopes", os.Getenv("MCP_OAUTH_SCOPES"), "tools allowed by scope: scope=tool,tool;scope=*. All the tools are allowed by default.")
+		limcalls  = flags.Int("limit-calls", 0, "maximum tool calls per minute of a session, 0 is unlimited.")
+		limconc   = flags.Int("limit-concurrent", 0, "maximum concurrent tool calls of a session, 0 is unlimited.")
+		limnavs   = flags.Int("limit-navigations", 0, "maximum navigations per minute of a session, 0 is unlimited.")
+		limbytes  = flags.Int64("limit-bytes", 0, "maximum total bytes of tool results of a session, 0 is unlimited.")
+		limperiod = flags.Duration("limit-bytes-period", 0, "period after which the -limit-bytes count is reset, 0 never resets it.")
+		keycalls  = flags.Int("key-limit-calls", 0, "maximum tool calls per minute of an API client across its sessions, 0 is unlimited.")
+		keyconc   = flags.Int("key-limit-concurrent", 0, "maximum concurrent tool calls of an API client across its sessions, 0 is unlimited.")
+		keynavs   = flags.Int("key-limit-navigations", 0, "maximum navigations per minute of an API client across its sessions, 0 is unlimited.")
+		keybytes  = flags.Int64("key-limit-bytes", 0, "maximum total bytes of tool results of an API client per -key-limit-bytes-period, 0 is unlimited.")
+		keyperiod = flags.Duration("key-limit-bytes-period", 24*time.Hour, "period after which the -key-limit-bytes count is reset.")
+		robots    = flags.String("robots-agent", os.Getenv("MCP_ROBOTS_AGENT"), "user-agent token matched against the robots.txt, enables the robots.txt and crawl delay politeness.")
+		exempt    = flags.Bool("robots-exempt-search", envBool("MCP_ROBOTS_EXEMPT_SEARCH", false), "exempt the search tool's results pages from the robots.txt rules with -robots-agent.")
+		delay     = flags.Duration("crawl-delay", time.Second, "minimum delay between two navigations to the same host with -robots-agent.")
+		keepalive = flags.Duration("keepalive", server.DefaultKeepAlive, "interval between the pings sent to the HTTP clients, 0 disables them.")
+		stdioping = flags.Duration("stdio-keepalive", 0, "interval between the pings sent to the stdio client, 0 disables them.")
+		grace     = flags.Duration("session-grace", server.DefaultSessionGrace, "duration a SSE session waits for the client to reconnect, 0 closes it on disconnection.")
 	)
 
 	// usage func declaration.
 	exec := args[0]
 	flags.Usage = func() {
-		fmt.Fprintf(stderr, "usage: %s sse|stdio|download|cleanup\n", exec)
+		fmt.Fprintf(stderr, "usage: %s sse|stdio|download|cleanup|hashkey\n", exec)
 		fmt.Fprintf(stderr, "Demo MCP server.\n")
 		fmt.Fprintf(stderr, "\nCommands:\n")
 		fmt.Fprintf(stderr, "\tstdio\t\tstarts the stdio server\n")
 		fmt.Fprintf(stderr, "\tsse\t\tstarts the HTTP SSE MCP server\n")
 		fmt.Fprintf(stderr, "\tdownload\tinstalls or updates the Lightpanda browser\n")
 		fmt.Fprintf(stderr, "\tcleanup\tremoves the Lightpanda browser\n")
+		fmt.Fprintf(stderr, "\thashkey\t\tprints the hash of the API key read from stdin\n")
 		fmt.Fprintf(stderr, "\nCommand line options:\n")
 		flags.PrintDefaults()
 		fmt.Fprintf(stderr, "\nEnvironment vars:\n")
 		fmt.Fprintf(stderr, "\tMCP_API_ADDRESS\t\tdefault %s\n", ApiDefaultAddress)
 		fmt.Fprintf(stderr, "\tMCP_CDP\n")
+		fmt.Fprintf(stderr, "\tMCP_SEARCH\t\tdefault %s\n", server.SearchDefault)
+		fmt.Fprintf(stderr, "\tMCP_SEARCH_URL\n")
+		fmt.Fprintf(stderr, "\tMCP_PROMPTS_DIR\n")
+		fmt.Fprintf(stderr, "\tMCP_ALLOW\n")
+		fmt.Fprintf(stderr, "\tMCP_DENY\n")
+		fmt.Fprintf(stderr, "\tMCP_ALLOW_PRIVATE\tdefault false\n")
+		fmt.Fprintf(stderr, "\tMCP_ROBOTS_AGENT\n")
+		fmt.Fprintf(stderr, "\tMCP_ROBOTS_EXEMPT_SEARCH\tdefault false\n")
+		fmt.Fprintf(stderr, "\tMCP_API_KEYS_FILE\n")
+		fmt.Fprintf(stderr, "\tMCP_API_KEYS\t\tcomma separated principal:key API keys\n")
+		fmt.Fprintf(stderr, "\tMCP_CORS_ORIGINS\n")
+		fmt.Fprintf(stderr, "\tMCP_OAUTH_RESOURCE\n")
+		fmt.Fprintf(stderr, "\tMCP_OAUTH_ISSUER\n")
+		fmt.Fprintf(stderr, "\tMCP_OAUTH_AUDIENCE\n")
+		fmt.Fprintf(stderr, "\tMCP_OAUTH_JWKS\n")
+		fmt.Fprintf(stderr, "\tMCP_OAUTH_SCOPES\n")
 	}
 	if err := flags.Parse(args[1:]); err != nil {
 		return err
@@ -95,9 +147,105 @@ func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	// commands w/o browser.
 	switch args[0] {
 	case "cleanup":
-		return cleanup(ctx)
+		return browser.Cleanup(ctx)
 	case "download":
-		return download(ctx)
+		return browser.Download(ctx)
+	case "hashkey":
+		key, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+		fmt.Fprintln(stdout, server.HashAPIKey(strings.TrimSpace(string(key))))
+		return nil
+	}
+
+	searchp, err := server.NewSearchProvider(*search, *searchurl)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	var userprompts []server.PromptTemplate
+	if *prompts != "" {
+		userprompts, err = server.LoadPrompts(*prompts)
+		if err != nil {
+			return fmt.Errorf("prompts: %w", err)
+		}
+	}
+
+	netpolicy, err := server.NewNetPolicy(splitList(*allow), splitList(*deny))
+	if err != nil {
+		return fmt.Errorf("net policy: %w", err)
+	}
+	netpolicy.AllowPrivate = *private
+
+	opts := []server.Option{
+		server.WithSearchProvider(searchp),
+		server.WithKeepAlive(*keepalive),
+		server.WithStdioKeepAlive(*stdioping),
+		server.WithSessionGrace(*grace),
+		server.WithNetPolicy(netpolicy),
+		server.WithCORSOrigins(splitList(*origins)...),
+	}
+
+	if *robots != "" {
+		politeness := server.NewPoliteness(*robots, *delay)
+		politeness.ExemptSearch = *exempt
+		opts = append(opts, server.WithPoliteness(politeness))
+	}
+
+	opts = append(opts, server.WithLimits(server.Limits{
+		CallsPerMinute:       *limcalls,
+		ConcurrentCalls:      *limconc,
+		NavigationsPerMinute: *limnavs,
+		MaxBytes:             *limbytes,
+		BytesPeriod:          *limperiod,
+	}, server.Limits{
+		CallsPerMinute:       *keycalls,
+		ConcurrentCalls:      *keyconc,
+		NavigationsPerMinute: *keynavs,
+		MaxBytes:             *keybytes,
+		BytesPeriod:          *keyperiod,
+	}))
+
+	apikeys := server.NewAPIKeys()
+	if *keysfile != "" {
+		apikeys, err = server.LoadAPIKeys(*keysfile)
+		if err != nil {
+			return fmt.Errorf("api keys: %w", err)
+		}
+	}
+	for _, v := range splitList(os.Getenv("MCP_API_KEYS")) {
+		principal, key, ok := strings.Cut(v, ":")
+		if !ok || principal == "" || key == "" {
+			return errors.New("api keys: MCP_API_KEYS expects principal:key values")
+		}
+		apikeys.Add(principal, key)
+	}
+	if apikeys.Len() > 0 {
+		opts = append(opts, server.WithAuthenticator(apikeys))
+	}
+
+	if *resource != "" {
+		toolscopes, err := server.ParseScopes(*scopes)
+		if err != nil {
+			return fmt.Errorf("oauth: %w", err)
+		}
+
+		oauth, err := server.NewOAuth(server.OAuthConfig{
+			Resource: *resource,
+			Issuer:   *issuer,
+			Audience: *audience,
+			JWKS:     *jwks,
+			Scopes:   toolscopes,
+		})
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithOAuth(oauth))
+	}
+
+	if apikeys.Len() == 0 && *resource == "" && args[0] == "sse" {
+		slog.Warn("the HTTP API isn't authenticated, use API keys or OAuth")
 	}
 
 	// commands with browser.
@@ -107,9 +255,9 @@ func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		browser, err := newbrowser(ctx)
+		cmd, err := browser.New(ctx)
 		if err != nil {
-			if errors.Is(err, ErrNoBrowser) {
+			if errors.Is(err, browser.ErrNoBrowser) {
 				return errors.New("browser not found. Please run gocmp download first.")
 			}
 			return fmt.Errorf("new browser: %w", err)
@@ -124,7 +272,7 @@ func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 
 		// Start the browser process.
 		go func() {
-			if err := browser.Run(); err != nil {
+			if err := cmd.Run(); err != nil {
 				slog.Error("run browser", slog.Any("err", err))
 			}
 			// The browser is ended, notify to stop waiting.
@@ -143,13 +291,18 @@ func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	)
 	defer cancel()
 
-	mcpsrv := NewMCPServer("lightpanda go mcp", "1.0.0", cdpctx)
+	mcpsrv := server.New(cdpctx, opts...)
+	for _, p := range userprompts {
+		if err := mcpsrv.RegisterPrompt(p); err != nil {
+			return fmt.Errorf("register prompt: %w", err)
+		}
+	}
 
 	switch args[0] {
 	case "stdio":
-		return runstd(ctx, stdin, stdout, mcpsrv)
+		return mcpsrv.ServeStdio(ctx, stdin, stdout)
 	case "sse":
-		return runapi(ctx, *apiaddr, mcpsrv)
+		return mcpsrv.ListenAndServe(ctx, *apiaddr)
 	}
 
 	flags.Usage()
@@ -165,3 +318,26 @@ func env(key, dflt string) string {
 
 	return val
 }
+
+// envBool returns the boolean value of the env var, dflt if it's not set or
+// not a boolean.
+func envBool(key string, dflt bool) bool {
+	val, err := strconv.ParseBool(env(key, ""))
+	if err != nil {
+		return dflt
+	}
+
+	return val
+}
+
+// splitList returns the non empty values of the comma separated list.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "github.com/lightpanda-io/gomcp/rpc"
+
+const CompletionCompleteMethod = "completion/complete"
+
+// The types of completion references.
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+)
+
+// CompletionReference identifies a prompt by its Name or a resource template
+// by its URI.
+type CompletionReference struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompletionCompleteRequest struct {
+	rpc.Request
+	Params struct {
+		Ref      CompletionReference `json:"ref"`
+		Argument struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"argument"`
+		Context struct {
+			// Arguments are the already resolved arguments.
+			Arguments map[string]string `json:"arguments"`
+		} `json:"context"`
+	} `json:"params"`
+}
+
+// MaxCompletionValues is the maximum number of values of a completion.
+const MaxCompletionValues = 100
+
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+type CompletionCompleteResponse struct {
+	Completion Completion `json:"completion"`
+}
+
+// NewCompletion returns the completion of the values, truncated to
+// MaxCompletionValues.
+func NewCompletion(values []string) Completion {
+	if values == nil {
+		values = []string{}
+	}
+	if len(values) <= MaxCompletionValues {
+		return Completion{Values: values}
+	}
+
+	return Completion{
+		Values:  values[:MaxCompletionValues],
+		Total:   len(values),
+		HasMore: true,
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+const ElicitationCreateMethod = "elicitation/create"
+
+type ElicitationCreateParams struct {
+	// Message is displayed to the user.
+	Message string `json:"message"`
+	// RequestedSchema is a flat object schema of primitive properties.
+	RequestedSchema *Schema `json:"requestedSchema"`
+}
+
+// The user's actions in response to an elicitation.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+type ElicitationCreateResult struct {
+	Action string `json:"action"`
+	// Content is set when the action is accept.
+	Content map[string]any `json:"content,omitempty"`
+}
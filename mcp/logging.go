@@ -0,0 +1,53 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "github.com/lightpanda-io/gomcp/rpc"
+
+// Logging levels, as defined by the syslog RFC 5424, from the least to the
+// most severe.
+const (
+	LevelDebug     = "debug"
+	LevelInfo      = "info"
+	LevelNotice    = "notice"
+	LevelWarning   = "warning"
+	LevelError     = "error"
+	LevelCritical  = "critical"
+	LevelAlert     = "alert"
+	LevelEmergency = "emergency"
+)
+
+// Levels lists the logging levels by severity.
+var Levels = []string{
+	LevelDebug, LevelInfo, LevelNotice, LevelWarning,
+	LevelError, LevelCritical, LevelAlert, LevelEmergency,
+}
+
+const LoggingSetLevelMethod = "logging/setLevel"
+
+type LoggingSetLevelRequest struct {
+	rpc.Request
+	Params struct {
+		Level string `json:"level"`
+	} `json:"params"`
+}
+
+const NotificationsMessageMethod = "notifications/message"
+
+type NotificationsMessage struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger,omitempty"`
+	Data   any    `json:"data"`
+}
@@ -15,20 +15,40 @@
 package mcp
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/lightpanda-io/gomcp/rpc"
 )
 
-// https://github.com/modelcontextprotocol/modelcontextprotocol/blob/main/schema/2025-03-26/schema.ts
+// https://github.com/modelcontextprotocol/modelcontextprotocol/blob/main/schema/2025-06-18/schema.ts
 
-const Version = "2024-11-05"
+const Version = "2025-06-18"
+
+// Versions lists the supported protocol versions, latest first.
+var Versions = []string{Version, "2025-03-26", "2024-11-05"}
+
+// NegotiateVersion returns the version requested by the client if it's
+// supported, the latest supported version otherwise.
+func NegotiateVersion(requested string) string {
+	if slices.Contains(Versions, requested) {
+		return requested
+	}
+	return Version
+}
 
 type Request any
 
 func Decode(r rpc.Request) (Request, error) {
+	if r.IsResponse() {
+		return Response(r), nil
+	}
+
 	switch r.Method {
+	case PingMethod:
+		return PingRequest(r), nil
 	case InitializeMethod:
 		rr := InitializeRequest{Request: r}
 		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
@@ -38,6 +58,8 @@ func Decode(r rpc.Request) (Request, error) {
 		return rr, nil
 	case NotificationsInitializedMethod:
 		return NotificationsInitializedRequest(r), nil
+	case NotificationsRootsListChangedMethod:
+		return NotificationsRootsListChangedRequest(r), nil
 	case NotificationsCancelledMethod:
 		rr := NotificationsCancelledRequest{Request: r}
 		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
@@ -46,11 +68,83 @@ func Decode(r rpc.Request) (Request, error) {
 
 		return rr, nil
 	case ResourcesListMethod:
-		return ResourcesListRequest(r), nil
+		rr := ResourcesListRequest{Request: r}
+		params, err := decodePaginatedParams(r.Params)
+		if err != nil {
+			return nil, err
+		}
+		rr.Params = params
+
+		return rr, nil
+	case ResourcesTemplatesListMethod:
+		rr := ResourcesTemplatesListRequest{Request: r}
+		params, err := decodePaginatedParams(r.Params)
+		if err != nil {
+			return nil, err
+		}
+		rr.Params = params
+
+		return rr, nil
+	case ResourcesReadMethod:
+		rr := ResourcesReadRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
+	case ResourcesSubscribeMethod:
+		rr := ResourcesSubscribeRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
+	case ResourcesUnsubscribeMethod:
+		rr := ResourcesUnsubscribeRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
 	case PromptsListMethod:
-		return PromptsListRequest(r), nil
+		rr := PromptsListRequest{Request: r}
+		params, err := decodePaginatedParams(r.Params)
+		if err != nil {
+			return nil, err
+		}
+		rr.Params = params
+
+		return rr, nil
+	case PromptsGetMethod:
+		rr := PromptsGetRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
+	case CompletionCompleteMethod:
+		rr := CompletionCompleteRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
+	case LoggingSetLevelMethod:
+		rr := LoggingSetLevelRequest{Request: r}
+		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+
+		return rr, nil
 	case ToolsListMethod:
-		return ToolsListRequest(r), nil
+		rr := ToolsListRequest{Request: r}
+		params, err := decodePaginatedParams(r.Params)
+		if err != nil {
+			return nil, err
+		}
+		rr.Params = params
+
+		return rr, nil
 	case ToolsCallMethod:
 		rr := ToolsCallRequest{Request: r}
 		if err := json.Unmarshal(r.Params, &rr.Params); err != nil {
@@ -63,8 +157,45 @@ func Decode(r rpc.Request) (Request, error) {
 	return nil, fmt.Errorf("invalid mcp: %s", r.Method)
 }
 
-type Capability struct{}
-type Capabilities map[string]Capability
+// Response is the client's response to a request sent by the server.
+type Response rpc.Request
+
+const PingMethod = "ping"
+
+type PingRequest rpc.Request
+
+// ServerCapabilities are the features implemented by the server, a nil
+// capability is not supported.
+type ServerCapabilities struct {
+	Tools       *ListChangedCapability `json:"tools,omitempty"`
+	Resources   *ResourcesCapability   `json:"resources,omitempty"`
+	Prompts     *ListChangedCapability `json:"prompts,omitempty"`
+	Logging     *EmptyCapability       `json:"logging,omitempty"`
+	Completions *EmptyCapability       `json:"completions,omitempty"`
+}
+
+// ClientCapabilities are the features implemented by the client, a nil
+// capability is not supported.
+type ClientCapabilities struct {
+	Roots       *ListChangedCapability `json:"roots,omitempty"`
+	Sampling    *EmptyCapability       `json:"sampling,omitempty"`
+	Elicitation *EmptyCapability       `json:"elicitation,omitempty"`
+}
+
+// EmptyCapability is a capability w/o option.
+type EmptyCapability struct{}
+
+type ListChangedCapability struct {
+	// ListChanged is set if the list changes are notified.
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+type ResourcesCapability struct {
+	// Subscribe is set if the clients can subscribe to resources updates.
+	Subscribe bool `json:"subscribe,omitempty"`
+	// ListChanged is set if the list changes are notified.
+	ListChanged bool `json:"listChanged,omitempty"`
+}
 
 const InitializeMethod = "initialize"
 
@@ -76,16 +207,16 @@ type Info struct {
 type InitializeRequest struct {
 	rpc.Request
 	Params struct {
-		ProtocolVersion string       `json:"protocolVersion"`
-		ClientInfo      Info         `json:"clientInfo"`
-		Capabilities    Capabilities `json:"capabilities"`
+		ProtocolVersion string             `json:"protocolVersion"`
+		ClientInfo      Info               `json:"clientInfo"`
+		Capabilities    ClientCapabilities `json:"capabilities"`
 	} `json:"params"`
 }
 
 type InitializeResponse struct {
-	ProtocolVersion string       `json:"protocolVersion"`
-	ServerInfo      Info         `json:"serverInfo"`
-	Capabilities    Capabilities `json:"capabilities"`
+	ProtocolVersion string             `json:"protocolVersion"`
+	ServerInfo      Info               `json:"serverInfo"`
+	Capabilities    ServerCapabilities `json:"capabilities"`
 }
 
 const NotificationsInitializedMethod = "notifications/initialized"
@@ -104,18 +235,30 @@ type NotificationsCancelledRequest struct {
 
 const ResourcesListMethod = "resources/list"
 
-type ResourcesListRequest rpc.Request
+type ResourcesListRequest struct {
+	rpc.Request
+	Params PaginatedParams `json:"params"`
+}
 
 const PromptsListMethod = "prompts/list"
 
-type PromptsListRequest rpc.Request
+type PromptsListRequest struct {
+	rpc.Request
+	Params PaginatedParams `json:"params"`
+}
 
 const ToolsListMethod = "tools/list"
 
-type ToolsListRequest rpc.Request
+type ToolsListRequest struct {
+	rpc.Request
+	Params PaginatedParams `json:"params"`
+}
+
+const NotificationsToolsListChangedMethod = "notifications/tools/list_changed"
 
 type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 const ToolsCallMethod = "tools/call"
@@ -131,12 +274,46 @@ type ToolsCallRequest struct {
 	} `json:"params"`
 }
 
+// ToolsCallContent is either a text or an image content.
 type ToolsCallContent struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
+	// Data is the base64 encoded image.
+	Data     string `json:"data"`
+	MimeType string `json:"mimeType"`
+}
+
+func NewTextContent(text string) ToolsCallContent {
+	return ToolsCallContent{Type: "text", Text: text}
+}
+
+func NewImageContent(data []byte, mimeType string) ToolsCallContent {
+	return ToolsCallContent{
+		Type:     "image",
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// MarshalJSON encodes only the fields of the content's type.
+func (c ToolsCallContent) MarshalJSON() ([]byte, error) {
+	if c.Type == "image" {
+		return json.Marshal(struct {
+			Type     string `json:"type"`
+			Data     string `json:"data"`
+			MimeType string `json:"mimeType"`
+		}{c.Type, c.Data, c.MimeType})
+	}
+
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}{c.Type, c.Text})
 }
 
 type ToolsCallResponse struct {
 	IsError bool               `json:"isError"`
 	Content []ToolsCallContent `json:"content"`
+	// StructuredContent is an object conforming to the tool's output schema.
+	StructuredContent any `json:"structuredContent,omitempty"`
 }
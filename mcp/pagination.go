@@ -0,0 +1,70 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// PaginatedParams are the params of the list requests.
+type PaginatedParams struct {
+	// Cursor is the opaque value returned as NextCursor by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Paginate returns the page of at most size items starting at the cursor
+// and the cursor of the next page, empty for the last page.
+// An empty cursor returns the first page, a size <= 0 returns all the items.
+func Paginate[T any](items []T, cursor string, size int) ([]T, string, error) {
+	offset := 0
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+		}
+		offset, err = strconv.Atoi(string(b))
+		if err != nil || offset < 0 || offset > len(items) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+		}
+	}
+
+	if size <= 0 || offset+size >= len(items) {
+		return items[offset:], "", nil
+	}
+
+	end := offset + size
+	next := base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+
+	return items[offset:end], next, nil
+}
+
+// decodePaginatedParams decodes the optional params of a list request.
+func decodePaginatedParams(raw json.RawMessage) (PaginatedParams, error) {
+	var p PaginatedParams
+	if len(raw) == 0 || string(raw) == "null" {
+		return p, nil
+	}
+
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return p, fmt.Errorf("decode: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	for _, tc := range []struct {
+		name  string
+		items []int
+		size  int
+		pages [][]int
+	}{
+		{name: "pages", items: items, size: 2, pages: [][]int{{1, 2}, {3, 4}, {5}}},
+		{name: "exact pages", items: items[:4], size: 2, pages: [][]int{{1, 2}, {3, 4}}},
+		{name: "single page", items: items, size: 5, pages: [][]int{items}},
+		{name: "unpaginated", items: items, size: 0, pages: [][]int{items}},
+		{name: "empty", items: []int{}, size: 2, pages: [][]int{{}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				cursor string
+				pages  [][]int
+			)
+			for {
+				page, next, err := Paginate(tc.items, cursor, tc.size)
+				if err != nil {
+					t.Fatalf("paginate %q: %v", cursor, err)
+				}
+				pages = append(pages, page)
+				if next == "" {
+					break
+				}
+				if len(pages) > len(tc.items)+1 {
+					t.Fatal("pagination doesn't end")
+				}
+				cursor = next
+			}
+
+			if !slices.EqualFunc(pages, tc.pages, slices.Equal) {
+				t.Errorf("got %v, want %v", pages, tc.pages)
+			}
+		})
+	}
+}
+
+func TestPaginateInvalidCursor(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	for _, cursor := range []string{"!", encode("x"), encode("-1"), encode("6")} {
+		if _, _, err := Paginate([]int{1, 2, 3, 4, 5}, cursor, 2); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: got %v", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/lightpanda-io/gomcp/rpc"
+)
+
+// ResourceNotFound is the rpc error code returned when reading an unknown
+// resource.
+const ResourceNotFound = -32002
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceTemplate struct {
+	// URITemplate is a RFC 6570 URI template.
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the content of a resource, either Text or Blob, the
+// base64 encoded binary data.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+	Blob     string `json:"blob"`
+}
+
+// MarshalJSON encodes the blob of the binary contents and the text of the
+// others, even if it's empty.
+func (c ResourceContents) MarshalJSON() ([]byte, error) {
+	if c.Blob != "" {
+		return json.Marshal(struct {
+			URI      string `json:"uri"`
+			MimeType string `json:"mimeType,omitempty"`
+			Blob     string `json:"blob"`
+		}{c.URI, c.MimeType, c.Blob})
+	}
+
+	return json.Marshal(struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text"`
+	}{c.URI, c.MimeType, c.Text})
+}
+
+type ResourcesListResponse struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+const ResourcesTemplatesListMethod = "resources/templates/list"
+
+type ResourcesTemplatesListRequest struct {
+	rpc.Request
+	Params PaginatedParams `json:"params"`
+}
+
+type ResourcesTemplatesListResponse struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
+}
+
+const ResourcesReadMethod = "resources/read"
+
+type ResourcesReadRequest struct {
+	rpc.Request
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+type ResourcesReadResponse struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+const ResourcesSubscribeMethod = "resources/subscribe"
+
+type ResourcesSubscribeRequest struct {
+	rpc.Request
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+const ResourcesUnsubscribeMethod = "resources/unsubscribe"
+
+type ResourcesUnsubscribeRequest struct {
+	rpc.Request
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+const NotificationsResourcesUpdatedMethod = "notifications/resources/updated"
+
+type NotificationsResourcesUpdated struct {
+	URI string `json:"uri"`
+}
+
+const NotificationsResourcesListChangedMethod = "notifications/resources/list_changed"
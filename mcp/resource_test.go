@@ -0,0 +1,54 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResourceContentsJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		c    ResourceContents
+		want string
+	}{
+		{
+			name: "text",
+			c:    ResourceContents{URI: "page://current/markdown", MimeType: "text/markdown", Text: "# Title"},
+			want: `{"uri":"page://current/markdown","mimeType":"text/markdown","text":"# Title"}`,
+		},
+		{
+			name: "empty text",
+			c:    ResourceContents{URI: "page://current/markdown", MimeType: "text/markdown"},
+			want: `{"uri":"page://current/markdown","mimeType":"text/markdown","text":""}`,
+		},
+		{
+			name: "blob",
+			c:    ResourceContents{URI: "screenshot://1", MimeType: "image/png", Blob: "iVBO"},
+			want: `{"uri":"screenshot://1","mimeType":"image/png","blob":"iVBO"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.c)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("got %s, want %s", b, tc.want)
+			}
+		})
+	}
+}
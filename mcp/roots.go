@@ -0,0 +1,33 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "github.com/lightpanda-io/gomcp/rpc"
+
+const RootsListMethod = "roots/list"
+
+// A Root is a location the client allows the server to work in.
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+type RootsListResult struct {
+	Roots []Root `json:"roots"`
+}
+
+const NotificationsRootsListChangedMethod = "notifications/roots/list_changed"
+
+type NotificationsRootsListChangedRequest rpc.Request
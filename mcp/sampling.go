@@ -0,0 +1,52 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+const SamplingCreateMessageMethod = "sampling/createMessage"
+
+// SamplingMessage is a message sent to or received from the client's LLM.
+type SamplingMessage struct {
+	Role    string           `json:"role"`
+	Content ToolsCallContent `json:"content"`
+}
+
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences are the server's preferences for the model selection,
+// the priorities are between 0 and 1.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+type SamplingCreateMessageParams struct {
+	Messages         []SamplingMessage `json:"messages"`
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+}
+
+type SamplingCreateMessageResult struct {
+	Role       string           `json:"role"`
+	Content    ToolsCallContent `json:"content"`
+	Model      string           `json:"model"`
+	StopReason string           `json:"stopReason,omitempty"`
+}
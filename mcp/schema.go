@@ -0,0 +1,167 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+// Schema is the subset of JSON Schema used to describe tools arguments and
+// results.
+// Create a schema with one of the NewSchema functions and refine it with the
+// With methods:
+//
+//	NewSchemaString("The page URL.").WithFormat("uri")
+//	NewSchemaInteger("The limit.").WithMinimum(1).WithDefault(10)
+type Schema struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+	Default     any    `json:"default,omitempty"`
+
+	// string
+	Format    string `json:"format,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+
+	// integer and number
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// array
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	// object
+	Properties           Properties `json:"properties,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+	AdditionalProperties *bool      `json:"additionalProperties,omitempty"`
+}
+
+type Properties map[string]*Schema
+
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+func NewSchemaString(description string) *Schema {
+	return &Schema{Type: TypeString, Description: description}
+}
+
+func NewSchemaInteger(description string) *Schema {
+	return &Schema{Type: TypeInteger, Description: description}
+}
+
+func NewSchemaNumber(description string) *Schema {
+	return &Schema{Type: TypeNumber, Description: description}
+}
+
+func NewSchemaBoolean(description string) *Schema {
+	return &Schema{Type: TypeBoolean, Description: description}
+}
+
+func NewSchemaArray(description string, items *Schema) *Schema {
+	return &Schema{Type: TypeArray, Description: description, Items: items}
+}
+
+// NewSchemaObject returns an object schema.
+// By default the object doesn't accept properties not listed in p.
+func NewSchemaObject(p Properties) *Schema {
+	if p == nil {
+		p = Properties{}
+	}
+
+	additional := false
+	return &Schema{
+		Type:                 TypeObject,
+		Properties:           p,
+		AdditionalProperties: &additional,
+	}
+}
+
+func (s *Schema) WithDescription(description string) *Schema {
+	s.Description = description
+	return s
+}
+
+// WithEnum restricts the values to the given list.
+func (s *Schema) WithEnum(values ...any) *Schema {
+	s.Enum = values
+	return s
+}
+
+// WithDefault documents the value used when the property is missing.
+func (s *Schema) WithDefault(v any) *Schema {
+	s.Default = v
+	return s
+}
+
+// WithFormat sets a string format, like uri or email.
+func (s *Schema) WithFormat(format string) *Schema {
+	s.Format = format
+	return s
+}
+
+// WithPattern sets the regular expression a string must match.
+func (s *Schema) WithPattern(pattern string) *Schema {
+	s.Pattern = pattern
+	return s
+}
+
+func (s *Schema) WithMinLength(n int) *Schema {
+	s.MinLength = &n
+	return s
+}
+
+func (s *Schema) WithMaxLength(n int) *Schema {
+	s.MaxLength = &n
+	return s
+}
+
+func (s *Schema) WithMinimum(v float64) *Schema {
+	s.Minimum = &v
+	return s
+}
+
+func (s *Schema) WithMaximum(v float64) *Schema {
+	s.Maximum = &v
+	return s
+}
+
+func (s *Schema) WithMinItems(n int) *Schema {
+	s.MinItems = &n
+	return s
+}
+
+func (s *Schema) WithMaxItems(n int) *Schema {
+	s.MaxItems = &n
+	return s
+}
+
+// WithRequired lists the object's mandatory properties.
+func (s *Schema) WithRequired(names ...string) *Schema {
+	s.Required = names
+	return s
+}
+
+// WithAdditionalProperties sets if the object accepts properties not
+// listed in its properties.
+func (s *Schema) WithAdditionalProperties(allowed bool) *Schema {
+	s.AdditionalProperties = &allowed
+	return s
+}
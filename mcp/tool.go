@@ -14,37 +14,58 @@
 
 package mcp
 
-type Schema any
-
-type SchemaType struct {
-	Type        string `json:"type"`
-	Description string `json:"description"`
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	InputSchema *Schema `json:"inputSchema"`
+	// OutputSchema describes the tool's structured content, it must be an
+	// object schema.
+	OutputSchema *Schema          `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
 }
 
-type schemaString SchemaType
+// ToolAnnotations describes the tool's behavior to the clients, which use
+// them to decide which tools require a user confirmation.
+// The annotations are hints, clients must not rely on them for security.
+// A nil hint is omitted and the client uses the spec default.
+type ToolAnnotations struct {
+	Title string `json:"title,omitempty"`
+	// ReadOnlyHint is true if the tool doesn't modify its environment.
+	// Default false.
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+	// DestructiveHint is true if the tool may perform destructive updates.
+	// Meaningful only when ReadOnlyHint is false. Default true.
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	// IdempotentHint is true if calling the tool repeatedly with the same
+	// arguments has no additional effect.
+	// Meaningful only when ReadOnlyHint is false. Default false.
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+	// OpenWorldHint is true if the tool interacts with external entities,
+	// like the web. Default true.
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
 
-func NewSchemaString(description string) schemaString {
-	return schemaString(SchemaType{Type: "string", Description: description})
+// NewToolAnnotations returns annotations with the title and no hint.
+func NewToolAnnotations(title string) *ToolAnnotations {
+	return &ToolAnnotations{Title: title}
 }
 
-type Properties map[string]Schema
+func (a *ToolAnnotations) WithReadOnly(v bool) *ToolAnnotations {
+	a.ReadOnlyHint = &v
+	return a
+}
 
-type schemaObject struct {
-	SchemaType
-	Properties           Properties `json:"properties"`
-	AdditionalProperties bool       `json:"additionalProperties"`
+func (a *ToolAnnotations) WithDestructive(v bool) *ToolAnnotations {
+	a.DestructiveHint = &v
+	return a
 }
 
-func NewSchemaObject(p map[string]Schema) schemaObject {
-	return schemaObject{
-		SchemaType: SchemaType{Type: "object"},
-		Properties: p,
-	}
+func (a *ToolAnnotations) WithIdempotent(v bool) *ToolAnnotations {
+	a.IdempotentHint = &v
+	return a
 }
 
-type Tool struct {
-	Name        string       `json:"name"`
-	Description string       `json:"description,omitempty"`
-	InputSchema schemaObject `json:"inputSchema"`
-	// TODO annotations
+func (a *ToolAnnotations) WithOpenWorld(v bool) *ToolAnnotations {
+	a.OpenWorldHint = &v
+	return a
 }
@@ -0,0 +1,282 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Violation is a value not conforming to its schema.
+type Violation struct {
+	// Path locates the value in the validated document, like limit or
+	// fields[2].name. It's empty for the document itself.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError is returned when a document doesn't conform to its schema.
+type ValidationError struct {
+	Violations []Violation `json:"violations"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "invalid params: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the JSON document against the schema.
+// It returns a *ValidationError listing every violation found.
+// An empty document is validated as null, or as an empty object for object
+// schemas.
+func (s *Schema) Validate(doc json.RawMessage) error {
+	doc = bytes.TrimSpace(doc)
+	if len(doc) == 0 {
+		doc = json.RawMessage("null")
+	}
+	if s.Type == TypeObject && bytes.Equal(doc, []byte("null")) {
+		doc = json.RawMessage("{}")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Violations: []Violation{{Message: fmt.Sprintf("invalid json: %s", err)}}}
+	}
+
+	var violations []Violation
+	s.validate("", v, &violations)
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (s *Schema) validate(path string, v any, violations *[]Violation) {
+	add := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !s.hasType(v) {
+		add("must be %s, got %s", article(s.Type), typeOf(v))
+		return
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equal(e, v) }) {
+		add("must be one of %s", enumString(s.Enum))
+	}
+
+	switch vv := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(vv)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err == nil && !re.MatchString(vv) {
+				add("must match the pattern %s", s.Pattern)
+			}
+		}
+		if s.Format != "" && !validFormat(s.Format, vv) {
+			add("must be a valid %s", s.Format)
+		}
+
+	case json.Number:
+		f, _ := vv.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			add("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			add("must be <= %v", *s.Maximum)
+		}
+
+	case []any:
+		if s.MinItems != nil && len(vv) < *s.MinItems {
+			add("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(vv) > *s.MaxItems {
+			add("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range vv {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := vv[name]; !ok {
+				*violations = append(*violations, Violation{Path: join(path, name), Message: "is required"})
+			}
+		}
+
+		// iterate in a stable order to return predictable violations.
+		names := make([]string, 0, len(vv))
+		for name := range vv {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*violations = append(*violations, Violation{Path: join(path, name), Message: "is not allowed"})
+				}
+				continue
+			}
+			p.validate(join(path, name), vv[name], violations)
+		}
+	}
+}
+
+// hasType returns true if the value is of the schema's type.
+// A schema w/o type accepts any value.
+func (s *Schema) hasType(v any) bool {
+	switch s.Type {
+	case "":
+		return true
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeNumber:
+		_, ok := v.(json.Number)
+		return ok
+	case TypeInteger:
+		// 1.0 or 1e2 are not decoded as integers by the tools.
+		n, ok := v.(json.Number)
+		return ok && !strings.ContainsAny(n.String(), ".eE")
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeArray:
+		_, ok := v.([]any)
+		return ok
+	case TypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	}
+
+	return false
+}
+
+var hostnameRe = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// validFormat checks the string formats known by the validator.
+// Unknown formats are accepted.
+func validFormat(format, v string) bool {
+	switch format {
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.IsAbs()
+	case "hostname":
+		return len(v) <= 253 && hostnameRe.MatchString(v)
+	case "email":
+		local, domain, ok := strings.Cut(v, "@")
+		return ok && local != "" && hostnameRe.MatchString(domain)
+	}
+
+	return true
+}
+
+// typeOf returns the JSON type name of a decoded value.
+func typeOf(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return TypeString
+	case json.Number:
+		if !strings.ContainsAny(vv.String(), ".eE") {
+			return TypeInteger
+		}
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	case []any:
+		return TypeArray
+	case map[string]any:
+		return TypeObject
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// equal compares an enum value with a decoded value.
+func equal(e, v any) bool {
+	if n, ok := v.(json.Number); ok {
+		f, _ := n.Float64()
+		switch ee := e.(type) {
+		case int:
+			return f == float64(ee)
+		case int64:
+			return f == float64(ee)
+		case float64:
+			return f == ee
+		}
+		return false
+	}
+
+	return reflect.DeepEqual(e, v)
+}
+
+func enumString(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		values = append(values, fmt.Sprintf("%v", e))
+	}
+	return strings.Join(values, ", ")
+}
+
+func article(typ string) string {
+	switch typ {
+	case TypeInteger, TypeObject, TypeArray:
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	search := NewSchemaObject(Properties{
+		"text":  NewSchemaString("The query.").WithMinLength(1).WithMaxLength(10),
+		"limit": NewSchemaInteger("The limit.").WithMinimum(1).WithMaximum(50),
+		"ratio": NewSchemaNumber("The ratio."),
+		"safe":  NewSchemaBoolean("Safe search.").WithEnum(true),
+		"url":   NewSchemaString("The URL.").WithFormat("uri"),
+		"email": NewSchemaString("The email.").WithFormat("email"),
+		"host":  NewSchemaString("The host.").WithFormat("hostname"),
+		"lang":  NewSchemaString("The lang.").WithPattern("^[a-z]{2}$").WithEnum("en", "fr"),
+		"tags": NewSchemaArray("The tags.", NewSchemaString("A tag.").WithMinLength(1)).
+			WithMinItems(1).WithMaxItems(2),
+		"filter": NewSchemaObject(Properties{
+			"site": NewSchemaString("The site."),
+		}).WithRequired("site").WithAdditionalProperties(false),
+	}).WithRequired("text")
+
+	for _, tc := range []struct {
+		name       string
+		doc        string
+		violations []string
+	}{
+		{name: "valid", doc: `{"text":"go","limit":10,"ratio":0.5,"safe":true,"url":"https://example.com","email":"a@example.com","host":"example.com","lang":"en","tags":["a"],"filter":{"site":"x"}}`},
+		{name: "unknown properties", doc: `{"text":"go","other":1}`, violations: []string{"other: is not allowed"}},
+		{name: "big integer", doc: `{"text":"go","limit":-12345678901234567890}`, violations: []string{"limit: must be >= 1"}},
+		{name: "integer as number", doc: `{"text":"go","ratio":2}`},
+		{name: "empty", doc: ``, violations: []string{"text: is required"}},
+		{name: "null", doc: `null`, violations: []string{"text: is required"}},
+		{name: "not an object", doc: `[]`, violations: []string{"must be an object, got array"}},
+		{name: "invalid json", doc: `{`, violations: []string{"invalid json: unexpected EOF"}},
+		{name: "string type", doc: `{"text":1}`, violations: []string{"text: must be a string, got integer"}},
+		{name: "string length", doc: `{"text":""}`, violations: []string{"text: must be at least 1 characters long"}},
+		{name: "string runes", doc: `{"text":"éééééééééé"}`},
+		{name: "string too long", doc: `{"text":"ééééééééééé"}`, violations: []string{"text: must be at most 10 characters long"}},
+		{name: "integer decimal", doc: `{"text":"go","limit":1.0}`, violations: []string{"limit: must be an integer, got number"}},
+		{name: "integer exponent", doc: `{"text":"go","limit":1e1}`, violations: []string{"limit: must be an integer, got number"}},
+		{name: "integer fraction", doc: `{"text":"go","limit":1.5}`, violations: []string{"limit: must be an integer, got number"}},
+		{name: "integer string", doc: `{"text":"go","limit":"1"}`, violations: []string{"limit: must be an integer, got string"}},
+		{name: "minimum", doc: `{"text":"go","limit":0}`, violations: []string{"limit: must be >= 1"}},
+		{name: "maximum", doc: `{"text":"go","limit":51}`, violations: []string{"limit: must be <= 50"}},
+		{name: "boolean", doc: `{"text":"go","safe":"true"}`, violations: []string{"safe: must be a boolean, got string"}},
+		{name: "enum", doc: `{"text":"go","safe":false}`, violations: []string{"safe: must be one of true"}},
+		{name: "uri", doc: `{"text":"go","url":"example.com"}`, violations: []string{"url: must be a valid uri"}},
+		{name: "email", doc: `{"text":"go","email":"example.com"}`, violations: []string{"email: must be a valid email"}},
+		{name: "hostname", doc: `{"text":"go","host":"-example.com"}`, violations: []string{"host: must be a valid hostname"}},
+		{name: "pattern and enum", doc: `{"text":"go","lang":"english"}`, violations: []string{"lang: must be one of en, fr", "lang: must match the pattern ^[a-z]{2}$"}},
+		{name: "min items", doc: `{"text":"go","tags":[]}`, violations: []string{"tags: must contain at least 1 items"}},
+		{name: "max items", doc: `{"text":"go","tags":["a","b","c"]}`, violations: []string{"tags: must contain at most 2 items"}},
+		{name: "items", doc: `{"text":"go","tags":["a",""]}`, violations: []string{"tags[1]: must be at least 1 characters long"}},
+		{name: "nested", doc: `{"text":"go","filter":{"other":1}}`, violations: []string{"filter.site: is required", "filter.other: is not allowed"}},
+		{name: "multiple", doc: `{"limit":0,"safe":1}`, violations: []string{"text: is required", "limit: must be >= 1", "safe: must be a boolean, got integer"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := search.Validate(json.RawMessage(tc.doc))
+
+			var got []string
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				for _, v := range verr.Violations {
+					got = append(got, v.String())
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(got, tc.violations) {
+				t.Errorf("got %q, want %q", got, tc.violations)
+			}
+		})
+	}
+}
@@ -22,9 +22,23 @@ import (
 
 const Version = "2.0"
 
+// Error codes defined by the JSON-RPC specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("code %d: %s", e.Code, e.Message)
 }
 
 type Request struct {
@@ -32,7 +46,10 @@ type Request struct {
 	Id      int             `json:"id,omitempty"`
 	Method  string          `json:"method,omitempty"`
 	Params  json.RawMessage `json:"params"`
-	Error   *Error          `json:"error,omitempty"`
+	// Result and Error are set when the message is a response to a request
+	// sent to the other party.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
 }
 
 var InvalidRequestErr = errors.New("invalid request")
@@ -42,25 +59,32 @@ func (req Request) Validate() error {
 		return InvalidRequestErr
 	}
 
-	if req.Method == "" && req.Error == nil {
+	if req.Method == "" && req.Error == nil && req.Result == nil {
 		return InvalidRequestErr
 	}
 
 	return nil
 }
 
+// IsResponse returns true if the message is a response to a request sent
+// to the other party.
+func (req Request) IsResponse() bool {
+	return req.Method == "" && req.Id != 0
+}
+
 func (req Request) Err() error {
 	if req.Error == nil {
 		return nil
 	}
 
-	return fmt.Errorf("code %d: %s", req.Error.Code, req.Error.Message)
+	return req.Error
 }
 
 type Response struct {
 	Version string `json:"jsonrpc"`
 	Id      int    `json:"id"`
-	Result  any    `json:"result"`
+	Result  any    `json:"result,omitempty"`
+	Error   *Error `json:"error,omitempty"`
 }
 
 func NewResponse(data any, id int) Response {
@@ -70,3 +94,48 @@ func NewResponse(data any, id int) Response {
 		Version: Version,
 	}
 }
+
+func NewErrorResponse(code int, message string, data any, id int) Response {
+	return Response{
+		Error: &Error{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+		Id:      id,
+		Version: Version,
+	}
+}
+
+// A Notification is a message w/o id, which doesn't expect a response.
+type Notification struct {
+	Version string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+func NewNotification(method string, params any) Notification {
+	return Notification{
+		Method:  method,
+		Params:  params,
+		Version: Version,
+	}
+}
+
+// A Call is a request sent to the other party, which expects a response
+// with the same id.
+type Call struct {
+	Version string `json:"jsonrpc"`
+	Id      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+func NewCall(method string, params any, id int) Call {
+	return Call{
+		Method:  method,
+		Params:  params,
+		Id:      id,
+		Version: Version,
+	}
+}
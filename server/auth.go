@@ -0,0 +1,221 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// A Principal is an authenticated client.
+type Principal struct {
+	// Name identifies the client, namespaced by its authentication method
+	// like key:<name> or oauth:<sub>, so an API key can't impersonate an
+	// OAuth subject with the same name.
+	Name string
+	// Scopes are the OAuth scopes granted to the client, nil if the client
+	// isn't authenticated by OAuth.
+	Scopes []string
+}
+
+// An Authenticator authenticates the HTTP requests.
+type Authenticator interface {
+	// Authenticate returns the principal of the request or an error
+	// wrapping ErrUnauthorized.
+	Authenticate(req *http.Request) (Principal, error)
+}
+
+// Authenticators tries the authenticators in order, the error of the last
+// one is returned if none succeeds.
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(req *http.Request) (Principal, error) {
+	err := ErrUnauthorized
+	for _, authn := range a {
+		p, aerr := authn.Authenticate(req)
+		if aerr == nil {
+			return p, nil
+		}
+		err = aerr
+	}
+
+	return Principal{}, err
+}
+
+// A challenger returns the WWW-Authenticate header for an authentication
+// error.
+type challenger interface {
+	challenge(err error) string
+}
+
+func (a Authenticators) challenge(err error) string {
+	for _, authn := range a {
+		if c, ok := authn.(challenger); ok {
+			return c.challenge(err)
+		}
+	}
+	return "Bearer"
+}
+
+// APIKeys authenticates the requests with an API key given as a bearer
+// token or with the x-api-key header.
+// Only the SHA-256 hashes of the keys are kept.
+type APIKeys struct {
+	mu     sync.RWMutex
+	hashes map[string]string
+}
+
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{hashes: make(map[string]string)}
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the key.
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// Add adds the key of the principal.
+func (k *APIKeys) Add(principal, key string) {
+	k.AddHash(principal, HashAPIKey(key))
+}
+
+// AddHash adds the key of the principal by its hash returned by HashAPIKey.
+func (k *APIKeys) AddHash(principal, hash string) {
+	k.mu.Lock()
+	k.hashes[strings.ToLower(hash)] = principal
+	k.mu.Unlock()
+}
+
+// Len returns the number of keys.
+func (k *APIKeys) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return len(k.hashes)
+}
+
+// LoadAPIKeys reads the key hashes of the file, one principal:hash per line.
+// The empty lines and the lines starting with # are ignored.
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open api keys: %w", err)
+	}
+	defer f.Close()
+
+	keys := NewAPIKeys()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principal, hash, ok := strings.Cut(line, ":")
+		if !ok || principal == "" {
+			return nil, fmt.Errorf("api keys line %d: expected principal:hash", n)
+		}
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("api keys line %d: invalid sha256 hash", n)
+		}
+
+		keys.AddHash(principal, hash)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("read api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (k *APIKeys) Authenticate(req *http.Request) (Principal, error) {
+	key := req.Header.Get("x-api-key")
+	if key == "" {
+		key = bearerToken(req)
+	}
+	if key == "" {
+		return Principal{}, fmt.Errorf("%w: missing api key", ErrUnauthorized)
+	}
+
+	k.mu.RLock()
+	principal, ok := k.hashes[HashAPIKey(key)]
+	k.mu.RUnlock()
+
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: invalid api key", ErrUnauthorized)
+	}
+
+	return Principal{Name: "key:" + principal}, nil
+}
+
+// bearerToken returns the bearer token of the authorization header.
+func bearerToken(req *http.Request) string {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+type principalKey struct{}
+
+// withPrincipal returns a context carrying the principal.
+func withPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal authenticated for the request.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// authenticate rejects the unauthenticated requests and adds the principal
+// to the request's context.
+// All the requests are accepted with an empty principal if authn is nil.
+func authenticate(authn Authenticator, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if authn == nil {
+			next(w, req.WithContext(withPrincipal(req.Context(), Principal{})))
+			return
+		}
+
+		p, err := authn.Authenticate(req)
+		if err != nil {
+			challenge := "Bearer"
+			if c, ok := authn.(challenger); ok {
+				challenge = c.challenge(err)
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next(w, req.WithContext(withPrincipal(req.Context(), p)))
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := NewAPIKeys()
+	keys.Add("alice", "alice secret")
+	keys.AddHash("bob", strings.ToUpper(HashAPIKey("bob secret")))
+
+	if n := keys.Len(); n != 2 {
+		t.Errorf("len: got %d, want 2", n)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"x-api-key", map[string]string{"x-api-key": "alice secret"}, "key:alice"},
+		{"bearer", map[string]string{"Authorization": "Bearer alice secret"}, "key:alice"},
+		{"lowercase bearer", map[string]string{"Authorization": "bearer bob secret"}, "key:bob"},
+		{"x-api-key first", map[string]string{"x-api-key": "bob secret", "Authorization": "Bearer alice secret"}, "key:bob"},
+		{"invalid x-api-key", map[string]string{"x-api-key": "wrong", "Authorization": "Bearer alice secret"}, ""},
+		{"basic", map[string]string{"Authorization": "Basic alice secret"}, ""},
+		{"invalid key", map[string]string{"Authorization": "Bearer wrong"}, ""},
+		{"missing key", nil, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/sse", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			p, err := keys.Authenticate(req)
+			if tc.want == "" {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Errorf("got %+v, %v", p, err)
+				}
+				return
+			}
+			if err != nil || p.Name != tc.want || p.Scopes != nil {
+				t.Errorf("got %+v, %v, want %s", p, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "keys.txt")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	keys, err := LoadAPIKeys(write(t, "# the agents\n\n  alice:"+HashAPIKey("a")+"  \nbob:"+strings.ToUpper(HashAPIKey("b"))+"\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if n := keys.Len(); n != 2 {
+		t.Errorf("len: got %d, want 2", n)
+	}
+	req := httptest.NewRequest("GET", "/sse", nil)
+	req.Header.Set("x-api-key", "b")
+	if p, err := keys.Authenticate(req); err != nil || p.Name != "key:bob" {
+		t.Errorf("authenticate: got %+v, %v", p, err)
+	}
+
+	for name, content := range map[string]string{
+		"no separator": "alice" + HashAPIKey("a"),
+		"no principal": ":" + HashAPIKey("a"),
+		"not hex":      "alice:" + strings.Repeat("z", 64),
+		"short hash":   "alice:" + HashAPIKey("a")[:32],
+	} {
+		if _, err := LoadAPIKeys(write(t, content)); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	if _, err := LoadAPIKeys(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
+
+// authnFunc is an Authenticator function.
+type authnFunc func(*http.Request) (Principal, error)
+
+func (f authnFunc) Authenticate(req *http.Request) (Principal, error) {
+	return f(req)
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := NewAPIKeys()
+	keys.Add("alice", "alice secret")
+	reject := authnFunc(func(*http.Request) (Principal, error) {
+		return Principal{}, ErrUnauthorized
+	})
+
+	for _, tc := range []struct {
+		name   string
+		authn  Authenticator
+		key    string
+		status int
+		want   string
+	}{
+		{name: "no authentication", authn: nil, status: http.StatusOK},
+		{name: "valid key", authn: keys, key: "alice secret", status: http.StatusOK, want: "key:alice"},
+		{name: "invalid key", authn: keys, key: "wrong", status: http.StatusUnauthorized},
+		{name: "authenticators", authn: Authenticators{reject, keys}, key: "alice secret", status: http.StatusOK, want: "key:alice"},
+		{name: "first authenticator", authn: Authenticators{keys, reject}, key: "alice secret", status: http.StatusOK, want: "key:alice"},
+		{name: "all rejected", authn: Authenticators{reject, keys}, key: "wrong", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *Principal
+			h := authenticate(tc.authn, func(w http.ResponseWriter, req *http.Request) {
+				p, ok := PrincipalFromContext(req.Context())
+				if !ok {
+					t.Error("no principal in context")
+				}
+				got = &p
+			})
+
+			req := httptest.NewRequest("GET", "/sse", nil)
+			if tc.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.key)
+			}
+			w := httptest.NewRecorder()
+			h(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("got status %d, want %d", w.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				if got != nil {
+					t.Error("handler called")
+				}
+				if c := w.Header().Get("WWW-Authenticate"); c != "Bearer" {
+					t.Errorf("got challenge %q", c)
+				}
+				return
+			}
+			if got == nil || got.Name != tc.want {
+				t.Errorf("got principal %+v, want %q", got, tc.want)
+			}
+		})
+	}
+}
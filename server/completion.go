@@ -0,0 +1,116 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+var ErrCompletionRef = errors.New("invalid completion reference")
+
+// maxHistory is the number of URLs kept in the navigation history.
+const maxHistory = 100
+
+// addHistory records the navigated URL, most recent first.
+func (c *MCPConn) addHistory(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history = slices.DeleteFunc(c.history, func(u string) bool { return u == url })
+	c.history = slices.Insert(c.history, 0, url)
+	if len(c.history) > maxHistory {
+		c.history = c.history[:maxHistory]
+	}
+}
+
+// History returns the URLs navigated by the connection, most recent first.
+func (c *MCPConn) History() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.history)
+}
+
+// Complete returns the values completing the argument of a prompt or of a
+// resource template, the values starting with value first.
+// The tools' arguments can't be completed, completion/complete only
+// references prompts and resource templates.
+func (c *MCPConn) Complete(ref mcp.CompletionReference, arg, value string) ([]string, error) {
+	var values []string
+
+	switch ref.Type {
+	case mcp.RefPrompt:
+		p, ok := c.srv.prompt(ref.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoPrompt, ref.Name)
+		}
+
+		values = p.Values[arg]
+		if arg == "url" {
+			values = slices.Concat(values, c.History())
+		}
+	case mcp.RefResource:
+		switch ref.URI {
+		case tabPageURI + "{tabId}/{kind}":
+			switch arg {
+			case "tabId":
+				if tab := c.tabID(); tab != "" {
+					values = []string{tab}
+				}
+			case "kind":
+				for _, r := range pageResources {
+					values = append(values, r.kind)
+				}
+			}
+		case screenshotURI + "{n}":
+			c.mu.Lock()
+			for _, s := range c.screenshots {
+				values = append(values, strconv.Itoa(s.n))
+			}
+			c.mu.Unlock()
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, ref.URI)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrCompletionRef, ref.Type)
+	}
+
+	return matchCompletions(values, value), nil
+}
+
+// matchCompletions returns the values starting with v followed by the
+// values containing v, case insensitively.
+func matchCompletions(values []string, v string) []string {
+	v = strings.ToLower(v)
+
+	var prefixed, contained []string
+	for _, val := range values {
+		lval := strings.ToLower(val)
+		switch {
+		case strings.HasPrefix(lval, v):
+			prefixed = append(prefixed, val)
+		case strings.Contains(lval, v):
+			contained = append(contained, val)
+		}
+	}
+
+	return append(prefixed, contained...)
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+func TestMatchCompletions(t *testing.T) {
+	values := []string{"Lightpanda Books", "Coffee", "lightpanda coffee", "Tea"}
+
+	for _, tc := range []struct {
+		value string
+		want  []string
+	}{
+		{"", values},
+		{"light", []string{"Lightpanda Books", "lightpanda coffee"}},
+		{"COFFEE", []string{"Coffee", "lightpanda coffee"}},
+		{"books", []string{"Lightpanda Books"}},
+		{"milk", nil},
+	} {
+		if got := matchCompletions(values, tc.value); !slices.Equal(got, tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	srv := New(context.Background())
+
+	urls := make([]string, 1, 4)
+	urls[0] = "https://lightpanda.io/"
+	err := srv.RegisterPrompt(PromptTemplate{
+		Prompt:   mcp.Prompt{Name: "test"},
+		Template: "{{.url}} {{.shop}}",
+		Values: map[string][]string{
+			"url":  urls,
+			"shop": {"Lightpanda Coffee", "Lightpanda Books"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("register prompt: %v", err)
+	}
+
+	c := srv.NewConn(func(string, any) error { return nil })
+	c.addHistory("https://example.com/")
+	c.addHistory("https://lightpanda.io/blog")
+
+	prompt := mcp.CompletionReference{Type: mcp.RefPrompt, Name: "test"}
+	for _, tc := range []struct {
+		name  string
+		ref   mcp.CompletionReference
+		arg   string
+		value string
+		want  []string
+		err   error
+	}{
+		{
+			name:  "prompt values",
+			ref:   prompt,
+			arg:   "shop",
+			value: "book",
+			want:  []string{"Lightpanda Books"},
+		},
+		{
+			name:  "url history",
+			ref:   prompt,
+			arg:   "url",
+			value: "https://lightpanda",
+			want:  []string{"https://lightpanda.io/", "https://lightpanda.io/blog"},
+		},
+		{
+			name: "unknown argument",
+			ref:  prompt,
+			arg:  "other",
+		},
+		{
+			name:  "resource kind",
+			ref:   mcp.CompletionReference{Type: mcp.RefResource, URI: tabPageURI + "{tabId}/{kind}"},
+			arg:   "kind",
+			value: "htm",
+			want:  []string{"html"},
+		},
+		{
+			name: "unknown prompt",
+			ref:  mcp.CompletionReference{Type: mcp.RefPrompt, Name: "unknown"},
+			arg:  "url",
+			err:  ErrNoPrompt,
+		},
+		{
+			name: "unknown resource",
+			ref:  mcp.CompletionReference{Type: mcp.RefResource, URI: "page://unknown/"},
+			arg:  "url",
+			err:  ErrResourceNotFound,
+		},
+		{
+			name: "tool reference",
+			ref:  mcp.CompletionReference{Type: "ref/tool", Name: "goto"},
+			arg:  "url",
+			err:  ErrCompletionRef,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.Complete(tc.ref, tc.arg, tc.value)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// the history isn't appended to the prompt's values.
+	if got := urls[:cap(urls)][1]; got != "" {
+		t.Errorf("prompt values modified: %q", got)
+	}
+}
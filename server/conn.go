@@ -0,0 +1,681 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+	"github.com/lightpanda-io/gomcp/rpc"
+)
+
+// A connection with a client
+type MCPConn struct {
+	srv       *MCPServer
+	principal Principal
+	limiter   *limiter
+
+	// tabmu serializes the tab creation.
+	tabmu sync.Mutex
+
+	sendmu sync.Mutex
+	send   SendFn
+
+	// pending are the requests sent to the client waiting for a response.
+	reqmu   sync.Mutex
+	reqid   int
+	pending map[int]chan rpc.Request
+
+	loglevel slog.LevelVar
+	logger   *slog.Logger
+
+	// mu guards the fields below, the tab's context included.
+	mu        sync.Mutex
+	cdpctx    context.Context
+	cdpcancel context.CancelFunc
+	// intercepted is set if the tab intercepts the requests.
+	intercepted   bool
+	clientinfo    mcp.Info
+	clientcaps    mcp.ClientCapabilities
+	origins       []string
+	rootserr      error
+	history       []string
+	toolnames     []string
+	subscriptions map[string]struct{}
+	screenshots   []screenshot
+	// screenshotseq numbers the screenshots.
+	screenshotseq int
+	// blocked is the last navigation blocked by the net policy or the roots.
+	blocked error
+
+	// rootsready is closed once the client's roots are first listed.
+	rootsready chan struct{}
+	rootsonce  sync.Once
+}
+
+// Send a message to the client.
+// Send is safe for concurrent use.
+func (c *MCPConn) Send(event string, data any) error {
+	c.sendmu.Lock()
+	defer c.sendmu.Unlock()
+
+	return c.send(event, data)
+}
+
+// Notify sends a notification to the client.
+func (c *MCPConn) Notify(method string, params any) error {
+	return c.Send("message", rpc.NewNotification(method, params))
+}
+
+// Request sends a request to the client and waits for its response.
+// The response's result is decoded into result if not nil.
+// An error response is returned as a *rpc.Error.
+func (c *MCPConn) Request(ctx context.Context, method string, params any, result any) error {
+	ch := make(chan rpc.Request, 1)
+
+	c.reqmu.Lock()
+	c.reqid++
+	id := c.reqid
+	c.pending[id] = ch
+	c.reqmu.Unlock()
+
+	defer func() {
+		c.reqmu.Lock()
+		delete(c.pending, id)
+		c.reqmu.Unlock()
+	}()
+
+	if err := c.Send("message", rpc.NewCall(method, params, id)); err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Error != nil {
+			return res.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	}
+}
+
+// resolve passes the client's response to the pending request.
+func (c *MCPConn) resolve(res rpc.Request) {
+	c.reqmu.Lock()
+	ch, ok := c.pending[res.Id]
+	c.reqmu.Unlock()
+
+	if !ok {
+		slog.Debug("unexpected response", slog.Int("id", res.Id))
+		return
+	}
+
+	ch <- res
+}
+
+// Ping sends a ping request to the client and waits for the response.
+func (c *MCPConn) Ping(ctx context.Context) error {
+	return c.Request(ctx, mcp.PingMethod, nil, nil)
+}
+
+func (c *MCPConn) Close() {
+	c.srv.removeConn(c)
+
+	c.mu.Lock()
+	cancel := c.cdpcancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// tab returns the context of the connection's tab, nil if no tab is open.
+func (c *MCPConn) tab() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cdpctx
+}
+
+// Principal returns the authenticated client of the connection, empty if
+// the transport isn't authenticated.
+func (c *MCPConn) Principal() Principal {
+	return c.principal
+}
+
+// initialize keeps the client's info and capabilities sent with the
+// initialize request.
+func (c *MCPConn) initialize(info mcp.Info, caps mcp.ClientCapabilities) {
+	c.mu.Lock()
+	c.clientinfo = info
+	c.clientcaps = caps
+	c.mu.Unlock()
+}
+
+// ClientInfo returns the client's name and version.
+func (c *MCPConn) ClientInfo() mcp.Info {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.clientinfo
+}
+
+// ClientCapabilities returns the capabilities declared by the client, the
+// server features relying on them must check them before use.
+func (c *MCPConn) ClientCapabilities() mcp.ClientCapabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.clientcaps
+}
+
+// ListTools returns the definitions of the tools available for the
+// connection.
+func (c *MCPConn) ListTools() []mcp.Tool {
+	tools := []mcp.Tool{}
+	for _, h := range c.srv.handlers() {
+		if available(h, c) {
+			tools = append(tools, h.Tool())
+		}
+	}
+
+	return tools
+}
+
+// toolsChanged notifies the client if the available tools changed since the
+// last call.
+func (c *MCPConn) toolsChanged() {
+	names := toolNames(c.ListTools())
+
+	c.mu.Lock()
+	changed := !slices.Equal(names, c.toolnames)
+	c.toolnames = names
+	c.mu.Unlock()
+
+	if changed {
+		c.notify(mcp.NotificationsToolsListChangedMethod, nil)
+	}
+}
+
+func toolNames(tools []mcp.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// connect opens the connection's tab, it's reused by the next navigations
+// while it's alive.
+func (c *MCPConn) connect() error {
+	c.tabmu.Lock()
+	defer c.tabmu.Unlock()
+
+	// the https roots restrict the documents' origins.
+	intercept := c.srv.netpolicy != nil || len(c.AllowedOrigins()) > 0
+
+	c.mu.Lock()
+	prev, prevcancel, intercepted := c.cdpctx, c.cdpcancel, c.intercepted
+	c.mu.Unlock()
+
+	// the tab is recreated if the interception is required by new roots.
+	if prev != nil && prev.Err() == nil && (intercepted || !intercept) {
+		return nil
+	}
+	if prevcancel != nil {
+		prevcancel()
+	}
+
+	ctx, cancel := chromedp.NewContext(c.srv.cdpctx)
+
+	// ensure the first tab is created
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return fmt.Errorf("new tab: %w", err)
+	}
+
+	if intercept {
+		chromedp.ListenTarget(ctx, func(ev any) {
+			c.interceptRequest(ctx, ev)
+		})
+		if err := chromedp.Run(ctx, fetch.Enable()); err != nil {
+			cancel()
+			return fmt.Errorf("enable fetch: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.cdpctx = ctx
+	c.cdpcancel = cancel
+	c.intercepted = intercept
+	c.mu.Unlock()
+
+	chromedp.ListenTarget(ctx, c.logConsole)
+
+	// the page tools are available with the new tab.
+	c.toolsChanged()
+
+	return nil
+}
+
+// logConsole logs the page's console errors and warnings and the uncaught
+// exceptions.
+func (c *MCPConn) logConsole(ev any) {
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		var level slog.Level
+		switch ev.Type {
+		case runtime.APITypeError, runtime.APITypeAssert:
+			level = slog.LevelError
+		case runtime.APITypeWarning:
+			level = slog.LevelWarn
+		default:
+			return
+		}
+
+		args := make([]string, 0, len(ev.Args))
+		for _, a := range ev.Args {
+			if a.Description != "" {
+				args = append(args, a.Description)
+				continue
+			}
+			args = append(args, strings.Trim(string(a.Value), `"`))
+		}
+
+		c.logger.Log(context.Background(), level, "console", slog.String("message", strings.Join(args, " ")))
+	case *runtime.EventExceptionThrown:
+		c.logger.Error("uncaught exception", slog.String("exception", ev.ExceptionDetails.Error()))
+	}
+}
+
+// interceptRequest checks the paused requests against the net policy and the
+// documents against the client's roots and fails the blocked ones.
+func (c *MCPConn) interceptRequest(ctx context.Context, ev any) {
+	e, ok := ev.(*fetch.EventRequestPaused)
+	if !ok {
+		return
+	}
+
+	// the event listener must not block.
+	go func() {
+		ctx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+
+		if err := c.checkRequest(ctx, e); err != nil {
+			c.logger.Warn("request blocked", slog.String("url", e.Request.URL), slog.Any("err", err))
+			if e.ResourceType == network.ResourceTypeDocument {
+				c.mu.Lock()
+				c.blocked = err
+				c.mu.Unlock()
+			}
+
+			if err := fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(ctx); err != nil {
+				slog.Debug("fail request", slog.Any("err", err))
+			}
+			return
+		}
+
+		if err := fetch.ContinueRequest(e.RequestID).Do(ctx); err != nil {
+			slog.Debug("continue request", slog.Any("err", err))
+		}
+	}()
+}
+
+// checkRequest returns an error if the request is blocked.
+func (c *MCPConn) checkRequest(ctx context.Context, e *fetch.EventRequestPaused) error {
+	if p := c.srv.netpolicy; p != nil {
+		if err := p.Check(ctx, e.Request.URL); err != nil {
+			return err
+		}
+	}
+
+	if e.ResourceType == network.ResourceTypeDocument {
+		ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+		defer cancel()
+		return c.checkOrigin(ctx, e.Request.URL)
+	}
+
+	return nil
+}
+
+// takeBlocked returns and resets the last navigation blocked by the net
+// policy or the roots.
+func (c *MCPConn) takeBlocked() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.blocked
+	c.blocked = nil
+	return err
+}
+
+// Navigate to a specified URL
+func (c *MCPConn) Goto(url string) (string, error) {
+	return c.navigate(url, false)
+}
+
+// navigate loads the URL in the connection's tab, search is true for the
+// search engines' results pages.
+func (c *MCPConn) navigate(url string, search bool) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rootsTimeout)
+	defer cancel()
+	if err := c.checkOrigin(ctx, url); err != nil {
+		return "", err
+	}
+
+	if p := c.srv.netpolicy; p != nil {
+		if err := p.Check(context.Background(), url); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.limitNavigation(); err != nil {
+		return "", err
+	}
+
+	if p := c.srv.politeness; p != nil {
+		wait := p.Wait
+		if search && p.ExemptSearch {
+			wait = p.Delay
+		}
+		if err := wait(context.Background(), url); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.connect(); err != nil {
+		return "", fmt.Errorf("browser connect: %w", err)
+	}
+
+	c.takeBlocked()
+	err := chromedp.Run(c.tab(), chromedp.Navigate(url))
+	if err != nil {
+		// a redirect can be blocked.
+		if berr := c.takeBlocked(); berr != nil {
+			return "", fmt.Errorf("navigate %s: %w", url, berr)
+		}
+		return "", fmt.Errorf("navigate %s: %w", url, err)
+	}
+
+	c.addHistory(url)
+	c.pageChanged()
+
+	return fmt.Sprintf("The browser correctly navigated to '%s', the page is loaded in the context of the browser and can be used.", url), nil
+}
+
+// Search the query with the search provider and return the results found
+// in the results page.
+func (c *MCPConn) Search(p SearchProvider, query SearchQuery) ([]SearchResult, error) {
+	// the search engines usually disallow their results pages to the
+	// robots, they can be exempted.
+	if _, err := c.navigate(p.URL(query), true); err != nil {
+		return nil, err
+	}
+
+	html, err := c.getHTML()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.Results(html)
+	if err != nil {
+		return nil, fmt.Errorf("search results: %w", err)
+	}
+
+	return res, nil
+}
+
+// tabID returns the id of the connection's tab, empty if no tab is open.
+func (c *MCPConn) tabID() string {
+	ctx := c.tab()
+	if ctx == nil {
+		return ""
+	}
+
+	cc := chromedp.FromContext(ctx)
+	if cc == nil || cc.Target == nil {
+		return ""
+	}
+
+	return string(cc.Target.TargetID)
+}
+
+// Capture a PNG screenshot of the page, of the viewport or of the full page.
+// The screenshot is kept available as a resource, its URI is returned.
+func (c *MCPConn) Screenshot(fullPage bool) ([]byte, string, error) {
+	ctx := c.tab()
+	if ctx == nil {
+		return nil, "", errors.New("no browser connection, try to use goto first")
+	}
+
+	var buf []byte
+	action := chromedp.CaptureScreenshot(&buf)
+	if fullPage {
+		action = chromedp.FullScreenshot(&buf, 100)
+	}
+
+	if err := chromedp.Run(ctx, action); err != nil {
+		return nil, "", fmt.Errorf("screenshot: %w", err)
+	}
+
+	// the oldest screenshots are evicted.
+	c.mu.Lock()
+	c.screenshotseq++
+	c.screenshots = append(c.screenshots, screenshot{n: c.screenshotseq, png: buf})
+	if len(c.screenshots) > maxScreenshots {
+		c.screenshots = slices.Delete(c.screenshots, 0, len(c.screenshots)-maxScreenshots)
+	}
+	uri := screenshotURI + strconv.Itoa(c.screenshotseq)
+	c.mu.Unlock()
+
+	c.notify(mcp.NotificationsResourcesListChangedMethod, nil)
+
+	return buf, uri, nil
+}
+
+// Return the document's outer HTML.
+func (c *MCPConn) getHTML() (string, error) {
+	ctx := c.tab()
+	if ctx == nil {
+		return "", errors.New("no browser connection, try to use goto first")
+	}
+
+	var html string
+	err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html))
+	if err != nil {
+		return "", fmt.Errorf("outerHTML: %w", err)
+	}
+
+	return html, nil
+}
+
+// Return the document's content in Markdown format.
+func (c *MCPConn) GetMarkdown() (string, error) {
+	html, err := c.getHTML()
+	if err != nil {
+		return "", err
+	}
+
+	converter := md.NewConverter("", true, nil)
+	content, err := converter.ConvertString(html)
+	if err != nil {
+		return "", fmt.Errorf("The document has been converted to markdown: %w", err)
+	}
+
+	return content, nil
+}
+
+// Return all links from a page
+func (c *MCPConn) GetLinks() ([]Link, error) {
+	html, err := c.getHTML()
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := parseLinks(html)
+	if err != nil {
+		return nil, fmt.Errorf("get links: %w", err)
+	}
+
+	return links, nil
+}
+
+// Return general information about the page.
+func (c *MCPConn) GetPageInfo() (PageInfo, error) {
+	html, err := c.getHTML()
+	if err != nil {
+		return PageInfo{}, err
+	}
+
+	info, err := parsePageInfo(html)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("get page info: %w", err)
+	}
+
+	if err := chromedp.Run(c.tab(), chromedp.Location(&info.URL)); err != nil {
+		return PageInfo{}, fmt.Errorf("location: %w", err)
+	}
+
+	return info, nil
+}
+
+// formActionScript returns the absolute URL the n-th form of the page is
+// submitted to. The action attribute is read since a field named action
+// shadows the form's property.
+const formActionScript = `(function(n) {
+	const form = document.forms[n];
+	if (!form) {
+		throw new Error("form not found");
+	}
+	return new URL(form.getAttribute("action") || "", document.baseURI).href;
+})(%d)`
+
+// submitNavigationTimeout bounds the wait of the navigation following a form
+// submission, the forms handled by a script don't navigate.
+const submitNavigationTimeout = 10 * time.Second
+
+// submitFormScript fills and submits the n-th form of the page.
+const submitFormScript = `(function(n, values) {
+	const form = document.forms[n];
+	if (!form) {
+		throw new Error("form not found");
+	}
+	for (const [name, value] of Object.entries(values)) {
+		const el = form.elements[name];
+		if (!el) {
+			throw new Error("field not found: " + name);
+		}
+		if (el.type === "checkbox") {
+			el.checked = value !== "" && value !== "false";
+			continue;
+		}
+		el.value = value;
+	}
+	if (form.requestSubmit) {
+		form.requestSubmit();
+	} else {
+		form.submit();
+	}
+	return true;
+})(%d, %s)`
+
+// SubmitForm fills the fields of the n-th form of the page, starting at 0,
+// with the values by field name and submits it.
+// The submission is a navigation: it's limited and spaced like Goto.
+func (c *MCPConn) SubmitForm(n int, values map[string]string) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("json encode: %w", err)
+	}
+
+	var action string
+	if err := chromedp.Run(c.tab(), chromedp.Evaluate(fmt.Sprintf(formActionScript, n), &action)); err != nil {
+		return "", fmt.Errorf("submit form: %w", err)
+	}
+
+	if err := c.limitNavigation(); err != nil {
+		return "", err
+	}
+
+	if p := c.srv.politeness; p != nil {
+		if err := p.Wait(context.Background(), action); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.tab(), submitNavigationTimeout)
+	defer cancel()
+
+	c.takeBlocked()
+	var ok bool
+	_, err = chromedp.RunResponse(ctx, chromedp.Evaluate(fmt.Sprintf(submitFormScript, n, b), &ok))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		// the form didn't navigate.
+	case err != nil:
+		// the navigation can be blocked.
+		if berr := c.takeBlocked(); berr != nil {
+			return "", fmt.Errorf("submit form: %w", berr)
+		}
+		return "", fmt.Errorf("submit form: %w", err)
+	}
+
+	var url string
+	if err := chromedp.Run(c.tab(), chromedp.Location(&url)); err != nil {
+		return "", fmt.Errorf("location: %w", err)
+	}
+
+	c.pageChanged()
+
+	return fmt.Sprintf("The form has been submitted, the browser is now on '%s'.", url), nil
+}
+
+// Return the forms of the page.
+func (c *MCPConn) GetForms() ([]Form, error) {
+	html, err := c.getHTML()
+	if err != nil {
+		return nil, err
+	}
+
+	forms, err := parseForms(html)
+	if err != nil {
+		return nil, fmt.Errorf("get forms: %w", err)
+	}
+
+	return forms, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+var (
+	ErrNoElicitation       = errors.New("the client doesn't support elicitation")
+	ErrElicitationDeclined = errors.New("the user declined the request")
+)
+
+// elicitationTimeout bounds the wait of the user's answer.
+const elicitationTimeout = 5 * time.Minute
+
+// Elicit asks the user for the values described by the flat object schema.
+// It returns ErrNoElicitation if the client doesn't support elicitation and
+// ErrElicitationDeclined if the user declined or cancelled the request.
+func (c *MCPConn) Elicit(ctx context.Context, message string, schema *mcp.Schema) (map[string]any, error) {
+	if c.ClientCapabilities().Elicitation == nil {
+		return nil, ErrNoElicitation
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, elicitationTimeout)
+	defer cancel()
+
+	var res mcp.ElicitationCreateResult
+	err := c.Request(ctx, mcp.ElicitationCreateMethod, mcp.ElicitationCreateParams{
+		Message:         message,
+		RequestedSchema: schema,
+	}, &res)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("elicit: no answer from the user after %s", elicitationTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("elicit: %w", err)
+	}
+
+	if res.Action != mcp.ElicitationAccept {
+		return nil, ErrElicitationDeclined
+	}
+
+	// the client is supposed to validate the content, check it anyway.
+	b, err := json.Marshal(res.Content)
+	if err != nil {
+		return nil, fmt.Errorf("elicit: json encode: %w", err)
+	}
+	if err := schema.Validate(b); err != nil {
+		return nil, fmt.Errorf("elicit: %w", err)
+	}
+
+	return res.Content, nil
+}
+
+// Confirm asks the user to confirm the action described by the message.
+// It returns ErrNoElicitation if the client doesn't support elicitation.
+func (c *MCPConn) Confirm(ctx context.Context, message string) (bool, error) {
+	content, err := c.Elicit(ctx, message, mcp.NewSchemaObject(mcp.Properties{
+		"confirm": mcp.NewSchemaBoolean("Confirm the action.").WithDefault(true),
+	}).WithRequired("confirm"))
+	if errors.Is(err, ErrElicitationDeclined) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	confirm, _ := content["confirm"].(bool)
+	return confirm, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+// Handler returns the HTTP handler serving the SSE transport.
+// Each call creates an independent set of sessions.
+func (s *MCPServer) Handler() http.Handler {
+	sessions := NewSessions()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /ack", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	var authn Authenticator
+	if len(s.authn) > 0 {
+		authn = s.authn
+	}
+
+	if s.oauth != nil {
+		mux.HandleFunc("GET "+wellKnownResource, cors(s.origins, s.oauth.serveMetadata))
+		mux.HandleFunc("GET "+wellKnownResource+"/{path...}", cors(s.origins, s.oauth.serveMetadata))
+	}
+
+	mux.HandleFunc("GET /sse", cors(s.origins, authenticate(authn, handleSSE(sessions, s))))
+	mux.HandleFunc("OPTIONS /sse", cors(s.origins, handleSSE(sessions, s)))
+	mux.HandleFunc("POST /messages", cors(s.origins, authenticate(authn, handleMessage(sessions, s))))
+	mux.HandleFunc("OPTIONS /messages", cors(s.origins, handleMessage(sessions, s)))
+
+	return mux
+}
+
+// ListenAndServe starts http API server.
+// Cancelling ctx will shutdown the http server gracefully.
+func (s *MCPServer) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	// shutdown api server on context cancelation
+	go func(ctx context.Context, srv *http.Server) {
+		<-ctx.Done()
+		slog.Debug("api server shutting down")
+		// we use context.Background() here b/c ctx is already canceled.
+		if err := srv.Shutdown(context.Background()); err != nil {
+			// context cancellation error is ignored.
+			if !errors.Is(err, context.Canceled) {
+				slog.Error("server shutdown", slog.String("err", err.Error()))
+			}
+		}
+	}(ctx, srv)
+
+	slog.Info("server listening", slog.String("addr", addr))
+
+	// ListenAndServe always returns a non-nil error.
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return fmt.Errorf("api server: %w", err)
+	}
+	slog.Info("api server shutdown")
+
+	return nil
+}
+
+// cors allows the cross origin requests from the origins, or from any origin
+// w/o credentials if origins is empty.
+func cors(origins []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if len(origins) == 0 {
+			w.Header().Set("access-control-allow-origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := req.Header.Get("Origin"); slices.Contains(origins, origin) {
+				w.Header().Set("access-control-allow-origin", origin)
+				w.Header().Set("access-control-allow-credentials", "true")
+			}
+		}
+
+		// the clients need the challenges to discover the authorization.
+		w.Header().Set("access-control-expose-headers", "WWW-Authenticate")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		if req.Method == http.MethodOptions {
+			w.Header().Set("access-control-allow-methods", "GET,POST")
+			w.Header().Set("access-control-allow-headers", "content-type,Accept,Authorization,x-api-key,Last-Event-ID")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next(w, req)
+	}
+}
+
+func handleSSE(sessions *Sessions, srv *MCPServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		principal, _ := PrincipalFromContext(ctx)
+
+		f, ok := w.(http.Flusher)
+		if !ok {
+			panic("response writer not a flusher")
+		}
+
+		st := &stream{w: w, f: f, cancel: cancel}
+
+		// resume the session of the last event received by the client or
+		// start a new one.
+		s, err := resumeSession(sessions, req.Header.Get("Last-Event-ID"), principal, st)
+		if err != nil {
+			slog.Debug("resume sse", slog.Any("err", err))
+			s = newSession(ctx, sessions, srv, principal)
+			if err := s.attach(st, sessionEndpoint(s), 0); err != nil {
+				return
+			}
+		}
+		defer s.detach(st)
+
+		slog.Debug("connect sse", slog.Any("id", s.id))
+		defer slog.Debug("disconnect sse", slog.Any("id", s.id))
+
+		// The SSE comments keep the idle proxies from closing the stream.
+		heartbeat := func() error {
+			return s.heartbeat(st)
+		}
+		go func() {
+			if err := s.conn.keepAlive(ctx, srv.keepalive, heartbeat); err != nil {
+				slog.Debug("keepalive", slog.Any("id", s.id), slog.Any("err", err))
+				cancel()
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+		case <-s.Done():
+		}
+	}
+}
+
+// The endpoint is relative to the SSE URL to allow mounting the handler under
+// a prefix.
+func sessionEndpoint(s *Session) string {
+	return fmt.Sprintf("messages?id=%s", s.id)
+}
+
+// newSession starts a session handling its requests until it's closed.
+func newSession(ctx context.Context, sessions *Sessions, srv *MCPServer, principal Principal) *Session {
+	s := NewSession()
+	s.principal = principal.Name
+	s.grace = srv.grace
+	s.conn = srv.newConn(s.send, principal)
+
+	sessions.Add(s)
+
+	go func() {
+		defer sessions.Remove(s.id)
+		defer s.conn.Close()
+		defer s.Close()
+
+		// the requests outlive the streams.
+		ctx := context.WithoutCancel(ctx)
+
+		for {
+			select {
+			case rreq := <-s.Requests():
+				if err := srv.Handle(ctx, rreq, s.conn, s.conn.Send); err != nil {
+					// disconnect on error
+					slog.Error("handle req", slog.Any("err", err))
+					return
+				}
+			case <-s.Done():
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// resumeSession attaches the stream to the session of the Last-Event-ID and
+// replays the events the client missed.
+func resumeSession(sessions *Sessions, lastEventId string, principal Principal, st *stream) (*Session, error) {
+	if lastEventId == "" {
+		return nil, ErrSessionReplay
+	}
+
+	id, last, err := parseEventId(lastEventId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the session must belong to the authenticated principal.
+	s, ok := sessions.Get(id)
+	if !ok || s.principal != principal.Name {
+		return nil, ErrSessionReplay
+	}
+
+	if err := s.attach(st, sessionEndpoint(s), last); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// sessionBusyTimeout is the maximum wait of a session to accept a message.
+const sessionBusyTimeout = 10 * time.Second
+
+func handleMessage(sessions *Sessions, srv *MCPServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// get the sessionId
+		var id SessionId
+		if err := id.Set(req.URL.Query().Get("id")); err != nil {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+
+		// retrieve the session
+		// the session must belong to the authenticated principal.
+		principal, _ := PrincipalFromContext(req.Context())
+		s, ok := sessions.Get(SessionId(id))
+		if ok && s.principal != principal.Name {
+			ok = false
+		}
+		if !ok {
+			slog.Debug("invalid session id", slog.Any("id", id))
+			http.Error(w, "id not found", http.StatusBadRequest)
+			return
+		}
+
+		mcpreq, err := srv.Decode(req.Body)
+		if err != nil {
+			slog.Error("message decode error", slog.Any("err", err))
+			if errors.Is(err, ErrRPCRequest) {
+				// TODO disconnect the client?
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte("Accepted")) // nolint:errcheck
+				return
+			}
+
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		// reject the tool calls not allowed by the token's scopes.
+		if r, ok := mcpreq.(mcp.ToolsCallRequest); ok && srv.oauth != nil && !srv.oauth.Allows(principal, r.Params.Name) {
+			w.Header().Set("WWW-Authenticate", srv.oauth.insufficientScope(r.Params.Name))
+			http.Error(w, "insufficient scope", http.StatusForbidden)
+			return
+		}
+
+		// don't wait forever for a busy or closing session.
+		select {
+		case s.Requests() <- mcpreq:
+		case <-s.Done():
+			http.Error(w, "session closed", http.StatusNotFound)
+			return
+		case <-req.Context().Done():
+			return
+		case <-time.After(sessionBusyTimeout):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "session busy", http.StatusTooManyRequests)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
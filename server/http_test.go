@@ -0,0 +1,154 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		origins     []string
+		origin      string
+		allow       string
+		credentials bool
+	}{
+		{name: "any origin", origin: "https://app.example.com", allow: "*"},
+		{name: "allowed origin", origins: []string{"https://app.example.com"}, origin: "https://app.example.com",
+			allow: "https://app.example.com", credentials: true},
+		{name: "disallowed origin", origins: []string{"https://app.example.com"}, origin: "https://evil.example.com"},
+		{name: "no origin", origins: []string{"https://app.example.com"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var called bool
+			h := cors(tc.origins, func(w http.ResponseWriter, _ *http.Request) {
+				called = true
+			})
+
+			req := httptest.NewRequest("GET", "/sse", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			w := httptest.NewRecorder()
+			h(w, req)
+
+			if !called {
+				t.Error("handler not called")
+			}
+			if got := w.Header().Get("access-control-allow-origin"); got != tc.allow {
+				t.Errorf("allow origin: got %q, want %q", got, tc.allow)
+			}
+			if got := w.Header().Get("access-control-allow-credentials") == "true"; got != tc.credentials {
+				t.Errorf("credentials: got %v, want %v", got, tc.credentials)
+			}
+			if got := w.Header().Get("access-control-expose-headers"); got != "WWW-Authenticate" {
+				t.Errorf("expose headers: got %q", got)
+			}
+			if len(tc.origins) > 0 && w.Header().Get("Vary") != "Origin" {
+				t.Errorf("vary: got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		h := cors(nil, func(w http.ResponseWriter, _ *http.Request) {
+			t.Error("handler called")
+		})
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("OPTIONS", "/sse", nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("got status %d", w.Code)
+		}
+		headers := w.Header().Get("access-control-allow-headers")
+		for _, h := range []string{"Authorization", "x-api-key", "Last-Event-ID"} {
+			if !strings.Contains(headers, h) {
+				t.Errorf("allow headers %q: missing %s", headers, h)
+			}
+		}
+	})
+}
+
+func TestSessionPrincipal(t *testing.T) {
+	keys := NewAPIKeys()
+	keys.Add("alice", "alice secret")
+	keys.Add("bob", "bob secret")
+
+	srv := New(context.Background(), WithAuthenticator(keys))
+
+	sessions := NewSessions()
+	s := NewSession()
+	s.principal = "key:alice"
+	s.grace = time.Minute
+	sessions.Add(s)
+	defer s.Close()
+
+	s.send("message", "hello")
+
+	t.Run("post", func(t *testing.T) {
+		h := authenticate(keys, handleMessage(sessions, srv))
+		ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+		go func() {
+			<-s.Requests()
+		}()
+
+		for _, tc := range []struct {
+			key    string
+			status int
+		}{
+			{"bob secret", http.StatusBadRequest},
+			{"alice secret", http.StatusAccepted},
+		} {
+			req := httptest.NewRequest("POST", "/messages?id="+s.id.String(), strings.NewReader(ping))
+			req.Header.Set("x-api-key", tc.key)
+			w := httptest.NewRecorder()
+			h(w, req)
+
+			if w.Code != tc.status {
+				t.Errorf("%s: got status %d, want %d", tc.key, w.Code, tc.status)
+			}
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		st, buf, _ := newTestStream()
+		if _, err := resumeSession(sessions, s.eventId(0), Principal{Name: "key:bob"}, st); !errors.Is(err, ErrSessionReplay) {
+			t.Errorf("other principal: got %v", err)
+		}
+		if _, err := resumeSession(sessions, s.eventId(0), Principal{Name: "oauth:alice"}, st); !errors.Is(err, ErrSessionReplay) {
+			t.Errorf("other authentication: got %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("written: %q", buf.String())
+		}
+
+		if _, err := resumeSession(sessions, s.eventId(0), Principal{Name: "key:alice"}, st); err != nil {
+			t.Fatalf("resume: %v", err)
+		}
+		if got := eventIds(buf); !slices.Equal(got, []string{"1"}) {
+			t.Errorf("replayed: got %v", got)
+		}
+	})
+}
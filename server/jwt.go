@@ -0,0 +1,353 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// jwtLeeway is the clock skew tolerated for the time claims.
+const jwtLeeway = time.Minute
+
+// jwtClaims are the claims of an access token used by the server.
+type jwtClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt int64       `json:"exp"`
+	NotBefore int64       `json:"nbf"`
+	// Scope is a space separated list of scopes, some issuers use the scp
+	// array instead.
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+}
+
+// scopes returns the token's scopes.
+func (c jwtClaims) scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	return c.Scp
+}
+
+// jwtAudience is either a string or an array of strings.
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = jwtAudience{s}
+		return nil
+	}
+
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return fmt.Errorf("aud: %w", err)
+	}
+	*a = ss
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// jwtTypes are the accepted token types: the RFC 9068 access tokens and the
+// generic JWTs issued by most authorization servers. The other types, like
+// the DPoP proofs or the logout tokens, aren't access tokens.
+var jwtTypes = []string{"", "jwt", "at+jwt", "application/at+jwt"}
+
+// validate checks the token type.
+func (h jwtHeader) validate() error {
+	if !slices.Contains(jwtTypes, strings.ToLower(h.Typ)) {
+		return fmt.Errorf("%w: unexpected typ %q", ErrInvalidToken, h.Typ)
+	}
+	return nil
+}
+
+// parseJWT decodes the compact serialized token w/o verifying it.
+func parseJWT(token string) (jwtHeader, jwtClaims, []byte, []byte, error) {
+	var (
+		header jwtHeader
+		claims jwtClaims
+	)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, claims, nil, nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return header, claims, nil, nil, fmt.Errorf("%w: header: %w", ErrInvalidToken, err)
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return header, claims, nil, nil, fmt.Errorf("%w: claims: %w", ErrInvalidToken, err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, claims, nil, nil, fmt.Errorf("%w: signature: %w", ErrInvalidToken, err)
+	}
+
+	return header, claims, []byte(parts[0] + "." + parts[1]), sig, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// minRSAKeySize is the minimum size in bits of the RSA keys.
+const minRSAKeySize = 2048
+
+// verifyJWT checks the signature of the signed content with the key.
+// Only the asymmetric algorithms are supported, the HMAC ones and none are
+// rejected.
+// The verification relies on the standard library's crypto packages only: the
+// server accepts a few algorithms from a single issuer and a JOSE dependency
+// would mostly bring the unused encryption and symmetric algorithms.
+func verifyJWT(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if len(alg) < 3 {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, alg)
+	}
+
+	var h hash.Hash
+	var ch crypto.Hash
+	switch alg[len(alg)-3:] {
+	case "256":
+		h, ch = sha256.New(), crypto.SHA256
+	case "384":
+		h, ch = sha512.New384(), crypto.SHA384
+	case "512":
+		h, ch = sha512.New(), crypto.SHA512
+	}
+
+	var err error
+	switch {
+	case alg == "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, sig) {
+			err = errors.New("bad signature")
+		}
+	case h == nil:
+		err = fmt.Errorf("unsupported alg %s", alg)
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			err = fmt.Errorf("%s requires a RSA key", alg)
+			break
+		}
+		if k.N.BitLen() < minRSAKeySize {
+			err = fmt.Errorf("RSA key smaller than %d bits", minRSAKeySize)
+			break
+		}
+		h.Write(signed)
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(k, ch, h.Sum(nil), sig)
+		} else {
+			err = rsa.VerifyPSS(k, ch, h.Sum(nil), sig, nil)
+		}
+	case strings.HasPrefix(alg, "ES"):
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve != ecdsaCurves[alg] {
+			err = fmt.Errorf("%s requires a %s key", alg, ecdsaCurveName(alg))
+			break
+		}
+		if size := (k.Curve.Params().BitSize + 7) / 8; len(sig) != 2*size {
+			err = errors.New("bad signature")
+			break
+		}
+		h.Write(signed)
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(k, h.Sum(nil), r, s) {
+			err = errors.New("bad signature")
+		}
+	default:
+		err = fmt.Errorf("unsupported alg %s", alg)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// ecdsaCurves are the curves required by the ECDSA algs.
+var ecdsaCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+func ecdsaCurveName(alg string) string {
+	if c, ok := ecdsaCurves[alg]; ok {
+		return c.Params().Name
+	}
+	return "supported curve"
+}
+
+// validate checks the subject, time, issuer and audience claims.
+func (c jwtClaims) validate(issuer, audience string, now time.Time) error {
+	switch {
+	case c.Subject == "":
+		return fmt.Errorf("%w: no subject", ErrInvalidToken)
+	case c.ExpiresAt == 0:
+		return fmt.Errorf("%w: no expiration", ErrInvalidToken)
+	case now.After(time.Unix(c.ExpiresAt, 0).Add(jwtLeeway)):
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	case c.NotBefore != 0 && now.Add(jwtLeeway).Before(time.Unix(c.NotBefore, 0)):
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	case issuer != "" && c.Issuer != issuer:
+		return fmt.Errorf("%w: unexpected issuer %s", ErrInvalidToken, c.Issuer)
+	case audience != "" && !slices.Contains(c.Audience, audience):
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// A jwk is a JSON web key, only the public key fields are used.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the signature keys of the JSON web key set by id.
+// The unsupported keys are ignored.
+func parseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the key, nil if its type isn't supported.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b64 := func(s string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := b64(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if exp.BitLen() > 31 || exp.Int64() < 3 || exp.Bit(0) == 0 {
+			return nil, errors.New("invalid e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecurve ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, ecurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, nil
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+
+		// check the point is on the curve.
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("invalid point size")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		new(big.Int).SetBytes(x).FillBytes(point[1 : 1+size])
+		new(big.Int).SetBytes(y).FillBytes(point[1+size:])
+		if _, err := ecurve.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid point: %w", err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := b64(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, nil
+}
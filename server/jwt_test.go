@@ -0,0 +1,250 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// signJWT signs the content with the key for the alg.
+func signJWT(t *testing.T, alg string, key crypto.Signer, signed []byte) []byte {
+	t.Helper()
+
+	var (
+		digest []byte
+		ch     crypto.Hash
+	)
+	switch alg[len(alg)-3:] {
+	case "256":
+		d := sha256.Sum256(signed)
+		digest, ch = d[:], crypto.SHA256
+	case "384":
+		d := sha512.Sum384(signed)
+		digest, ch = d[:], crypto.SHA384
+	case "512":
+		d := sha512.Sum512(signed)
+		digest, ch = d[:], crypto.SHA512
+	}
+
+	var (
+		sig []byte
+		err error
+	)
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg[0] == 'P' {
+			sig, err = rsa.SignPSS(rand.Reader, k, ch, digest, nil)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, ch, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, signed)
+	}
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return sig
+}
+
+func TestVerifyJWT(t *testing.T) {
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eckeys := map[string]*ecdsa.PrivateKey{}
+	for name, c := range map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()} {
+		if eckeys[name], err = ecdsa.GenerateKey(c, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, edkey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallkey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := []byte("header.claims")
+
+	for _, tc := range []struct {
+		name    string
+		alg     string
+		signer  crypto.Signer
+		sigalg  string
+		key     crypto.PublicKey
+		tamper  func([]byte) []byte
+		invalid bool
+	}{
+		{name: "RS256", alg: "RS256", signer: rsakey, key: rsakey.Public()},
+		{name: "RS512", alg: "RS512", signer: rsakey, key: rsakey.Public()},
+		{name: "PS256", alg: "PS256", signer: rsakey, key: rsakey.Public()},
+		{name: "ES256", alg: "ES256", signer: eckeys["P-256"], key: eckeys["P-256"].Public()},
+		{name: "ES384", alg: "ES384", signer: eckeys["P-384"], key: eckeys["P-384"].Public()},
+		{name: "ES512", alg: "ES512", signer: eckeys["P-521"], key: eckeys["P-521"].Public()},
+		{name: "EdDSA", alg: "EdDSA", signer: edkey, key: edkey.Public()},
+		{name: "tampered", alg: "RS256", signer: rsakey, key: rsakey.Public(), invalid: true,
+			tamper: func(b []byte) []byte { b[0] ^= 1; return b }},
+		{name: "RS as PS", alg: "PS256", sigalg: "RS256", signer: rsakey, key: rsakey.Public(), invalid: true},
+		{name: "none", alg: "none", signer: rsakey, sigalg: "RS256", key: rsakey.Public(), invalid: true},
+		{name: "HS256", alg: "HS256", signer: rsakey, sigalg: "RS256", key: rsakey.Public(), invalid: true},
+		{name: "RS256 with EC key", alg: "RS256", signer: eckeys["P-256"], key: eckeys["P-256"].Public(), invalid: true},
+		{name: "ES256 with RSA key", alg: "ES256", signer: rsakey, sigalg: "RS256", key: rsakey.Public(), invalid: true},
+		{name: "ES256 with P-384 key", alg: "ES256", signer: eckeys["P-384"], key: eckeys["P-384"].Public(), invalid: true},
+		{name: "ES384 with P-256 key", alg: "ES384", signer: eckeys["P-256"], key: eckeys["P-256"].Public(), invalid: true},
+		{name: "ES256 short signature", alg: "ES256", signer: eckeys["P-256"], key: eckeys["P-256"].Public(), invalid: true,
+			tamper: func(b []byte) []byte { return b[1:] }},
+		{name: "ES256 long signature", alg: "ES256", signer: eckeys["P-256"], key: eckeys["P-256"].Public(), invalid: true,
+			tamper: func(b []byte) []byte { return append([]byte{0}, b...) }},
+		{name: "EdDSA with RSA key", alg: "EdDSA", signer: edkey, key: rsakey.Public(), invalid: true},
+		{name: "RS256 with 1024 bits key", alg: "RS256", signer: smallkey, key: smallkey.Public(), invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sigalg := tc.sigalg
+			if sigalg == "" {
+				sigalg = tc.alg
+			}
+			sig := signJWT(t, sigalg, tc.signer, signed)
+			if tc.tamper != nil {
+				sig = tc.tamper(sig)
+			}
+
+			err := verifyJWT(tc.alg, tc.key, signed, sig)
+			if invalid := err != nil; invalid != tc.invalid {
+				t.Fatalf("invalid: got %v, want %v: %v", invalid, tc.invalid, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("error not ErrInvalidToken: %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTClaimsValidate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	exp := now.Add(time.Hour).Unix()
+
+	for _, tc := range []struct {
+		name     string
+		claims   jwtClaims
+		issuer   string
+		audience string
+		invalid  bool
+	}{
+		{name: "valid", claims: jwtClaims{Subject: "a", ExpiresAt: exp}},
+		{name: "no subject", claims: jwtClaims{ExpiresAt: exp}, invalid: true},
+		{name: "no expiration", claims: jwtClaims{Subject: "a"}, invalid: true},
+		{name: "expired", claims: jwtClaims{Subject: "a", ExpiresAt: now.Add(-2 * time.Minute).Unix()}, invalid: true},
+		{name: "expired in leeway", claims: jwtClaims{Subject: "a", ExpiresAt: now.Add(-30 * time.Second).Unix()}},
+		{name: "not before", claims: jwtClaims{Subject: "a", ExpiresAt: exp, NotBefore: now.Add(2 * time.Minute).Unix()}, invalid: true},
+		{name: "issuer", issuer: "https://as", claims: jwtClaims{Subject: "a", ExpiresAt: exp, Issuer: "https://as"}},
+		{name: "bad issuer", issuer: "https://as", claims: jwtClaims{Subject: "a", ExpiresAt: exp, Issuer: "https://other"}, invalid: true},
+		{name: "audience", audience: "https://rs", claims: jwtClaims{Subject: "a", ExpiresAt: exp, Audience: jwtAudience{"x", "https://rs"}}},
+		{name: "bad audience", audience: "https://rs", claims: jwtClaims{Subject: "a", ExpiresAt: exp, Audience: jwtAudience{"https://other"}}, invalid: true},
+		{name: "no audience", audience: "https://rs", claims: jwtClaims{Subject: "a", ExpiresAt: exp}, invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.claims.validate(tc.issuer, tc.audience, now)
+			if invalid := err != nil; invalid != tc.invalid {
+				t.Errorf("invalid: got %v, want %v: %v", invalid, tc.invalid, err)
+			}
+		})
+	}
+}
+
+func TestParseJWT(t *testing.T) {
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	token := enc(map[string]string{"alg": "ES256", "kid": "k1"}) + "." +
+		enc(map[string]any{"sub": "a", "aud": "https://rs", "scope": "read write"}) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte("sig"))
+
+	header, claims, _, sig, err := parseJWT(token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if header.Alg != "ES256" || header.Kid != "k1" || string(sig) != "sig" {
+		t.Errorf("header: %+v %q", header, sig)
+	}
+	if claims.Subject != "a" || len(claims.Audience) != 1 || len(claims.scopes()) != 2 {
+		t.Errorf("claims: %+v", claims)
+	}
+
+	for _, bad := range []string{"", "a.b", "a.b.c.d", "!.e30.", enc("x") + ".e30."} {
+		if _, _, _, _, err := parseJWT(bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%q: got %v", bad, err)
+		}
+	}
+}
+
+func TestJWTHeaderValidate(t *testing.T) {
+	for typ, valid := range map[string]bool{
+		"":                   true,
+		"JWT":                true,
+		"at+jwt":             true,
+		"application/at+JWT": true,
+		"dpop+jwt":           false,
+		"logout+jwt":         false,
+	} {
+		err := jwtHeader{Alg: "ES256", Typ: typ}.validate()
+		if (err == nil) != valid {
+			t.Errorf("%q: got %v", typ, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%q: error not ErrInvalidToken: %v", typ, err)
+		}
+	}
+}
+
+func TestParseJWKSExponent(t *testing.T) {
+	n := base64.RawURLEncoding.EncodeToString(make([]byte, 256))
+	for e, valid := range map[string]bool{
+		"AQAB":        true,
+		"Aw":          true,
+		"AQ":          false,
+		"AAE":         false,
+		"Ag":          false,
+		"AQAAAAAAAAE": false,
+	} {
+		jwks := `{"keys":[{"kty":"RSA","kid":"k1","n":"` + n + `","e":"` + e + `"}]}`
+		if _, err := parseJWKS([]byte(jwks)); (err == nil) != valid {
+			t.Errorf("e %s: got %v", e, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/rpc"
+)
+
+// DefaultKeepAlive is the default interval between the server pings.
+const DefaultKeepAlive = 30 * time.Second
+
+var ErrPingTimeout = errors.New("ping timeout")
+
+// keepAlive pings the client every interval until ctx is done.
+// heartbeat, if not nil, is called before each ping to keep the transport
+// alive, like a SSE comment.
+// It returns an error when the client doesn't answer a ping before the next
+// one or when the heartbeat fails.
+func (c *MCPConn) keepAlive(ctx context.Context, interval time.Duration, heartbeat func() error) error {
+	if interval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if heartbeat != nil {
+			if err := heartbeat(); err != nil {
+				return fmt.Errorf("heartbeat: %w", err)
+			}
+		}
+
+		pctx, cancel := context.WithTimeout(ctx, interval)
+		err := c.Ping(pctx)
+		cancel()
+
+		var rerr *rpc.Error
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, context.DeadlineExceeded):
+			return ErrPingTimeout
+		case errors.As(err, &rerr):
+			// the client answered with an error, it's still alive.
+			slog.Debug("ping", slog.Any("err", err))
+		default:
+			return fmt.Errorf("ping: %w", err)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimited is the rpc error code returned when a limit is exceeded.
+const RateLimited = -32029
+
+// Limits are the usage limits of a session or of a principal across its
+// sessions. The zero values are unlimited.
+type Limits struct {
+	// CallsPerMinute is the rate of the tool calls.
+	CallsPerMinute int
+	// ConcurrentCalls is the number of tool calls running at the same time.
+	ConcurrentCalls int
+	// NavigationsPerMinute is the rate of the navigations.
+	NavigationsPerMinute int
+	// MaxBytes is the total size of the tool results per BytesPeriod.
+	MaxBytes int64
+	// BytesPeriod is the period after which the results size is reset, zero
+	// never resets it.
+	BytesPeriod time.Duration
+}
+
+// The names of the limits.
+const (
+	limitCalls       = "calls_per_minute"
+	limitConcurrent  = "concurrent_calls"
+	limitNavigations = "navigations_per_minute"
+	limitBytes       = "max_bytes"
+)
+
+// A RateLimitError is returned when a limit is exceeded.
+type RateLimitError struct {
+	// Limit is the name of the exceeded limit.
+	Limit string `json:"limit"`
+	// RetryAfter is the duration to wait before retrying, zero if the limit
+	// is a quota which can't be retried.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter == 0 {
+		return fmt.Sprintf("rate limited: %s exceeded", e.Limit)
+	}
+	return fmt.Sprintf("rate limited: %s exceeded, retry after %s", e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// MarshalJSON encodes the error with the retry delay in seconds.
+func (e *RateLimitError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Limit      string `json:"limit"`
+		RetryAfter int    `json:"retryAfter,omitempty"`
+	}{e.Limit, int(math.Ceil(e.RetryAfter.Seconds()))})
+}
+
+// A bucket is a token bucket refilled at rate tokens per minute.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take consumes a token, it returns the wait until the next token otherwise.
+func (b *bucket) take(rate int, now time.Time) (time.Duration, bool) {
+	if rate <= 0 {
+		return 0, true
+	}
+
+	if b.last.IsZero() {
+		b.tokens = float64(rate)
+	} else {
+		b.tokens = min(float64(rate), b.tokens+now.Sub(b.last).Minutes()*float64(rate))
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	return time.Duration((1 - b.tokens) / float64(rate) * float64(time.Minute)), false
+}
+
+// full returns true if the bucket is refilled.
+func (b *bucket) full(rate int, now time.Time) bool {
+	return rate <= 0 || b.last.IsZero() || b.tokens+now.Sub(b.last).Minutes()*float64(rate) >= float64(rate)
+}
+
+// A limiter tracks the usage of a session or of a principal.
+type limiter struct {
+	limits Limits
+
+	mu       sync.Mutex
+	calls    bucket
+	navs     bucket
+	inflight int
+	bytes    int64
+	// period is the start of the current bytes period.
+	period time.Time
+}
+
+func newLimiter(l Limits) *limiter {
+	return &limiter{limits: l}
+}
+
+// acquireCall reserves a tool call, releaseCall must be called at the end of
+// the call.
+func (l *limiter) acquireCall() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.resetBytes(now)
+
+	if l.limits.MaxBytes > 0 && l.bytes >= l.limits.MaxBytes {
+		var wait time.Duration
+		if l.limits.BytesPeriod > 0 {
+			wait = l.period.Add(l.limits.BytesPeriod).Sub(now)
+		}
+		return &RateLimitError{Limit: limitBytes, RetryAfter: wait}
+	}
+	if l.limits.ConcurrentCalls > 0 && l.inflight >= l.limits.ConcurrentCalls {
+		return &RateLimitError{Limit: limitConcurrent, RetryAfter: time.Second}
+	}
+	if wait, ok := l.calls.take(l.limits.CallsPerMinute, now); !ok {
+		return &RateLimitError{Limit: limitCalls, RetryAfter: wait}
+	}
+
+	l.inflight++
+	return nil
+}
+
+// resetBytes resets the results size at the end of the bytes period.
+// The lock must be held.
+func (l *limiter) resetBytes(now time.Time) {
+	if l.limits.BytesPeriod <= 0 {
+		return
+	}
+	if l.period.IsZero() || now.Sub(l.period) >= l.limits.BytesPeriod {
+		l.period = now
+		l.bytes = 0
+	}
+}
+
+// idle returns true if the limiter is in its initial state and can be
+// forgotten.
+func (l *limiter) idle(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.resetBytes(now)
+	return l.inflight == 0 && l.bytes == 0 &&
+		l.calls.full(l.limits.CallsPerMinute, now) &&
+		l.navs.full(l.limits.NavigationsPerMinute, now)
+}
+
+// releaseCall ends a tool call which returned n bytes.
+func (l *limiter) releaseCall(n int64) {
+	l.mu.Lock()
+	l.inflight--
+	l.bytes += n
+	l.mu.Unlock()
+}
+
+// cancelCall cancels a reserved tool call which didn't run.
+func (l *limiter) cancelCall() {
+	l.mu.Lock()
+	l.inflight--
+	if l.limits.CallsPerMinute > 0 {
+		l.calls.tokens++
+	}
+	l.mu.Unlock()
+}
+
+// navigation reserves a navigation.
+func (l *limiter) navigation() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if wait, ok := l.navs.take(l.limits.NavigationsPerMinute, time.Now()); !ok {
+		return &RateLimitError{Limit: limitNavigations, RetryAfter: wait}
+	}
+	return nil
+}
+
+// cancelNavigation cancels a reserved navigation which didn't run.
+func (l *limiter) cancelNavigation() {
+	l.mu.Lock()
+	if l.limits.NavigationsPerMinute > 0 {
+		l.navs.tokens++
+	}
+	l.mu.Unlock()
+}
+
+// limiters returns the limiters of the connection: the session's one and
+// the principal's one for the authenticated connections.
+func (c *MCPConn) limiters() []*limiter {
+	l := []*limiter{c.limiter}
+	if c.principal.Name != "" {
+		l = append(l, c.srv.principalLimiter(c.principal.Name))
+	}
+	return l
+}
+
+// acquireCall reserves a tool call for the session and the principal.
+// The returned release func must be called with the size of the result.
+func (c *MCPConn) acquireCall() (func(n int64), error) {
+	limiters := c.limiters()
+	for i, l := range limiters {
+		if err := l.acquireCall(); err != nil {
+			for _, ll := range limiters[:i] {
+				ll.cancelCall()
+			}
+			return nil, err
+		}
+	}
+
+	return func(n int64) {
+		for _, l := range limiters {
+			l.releaseCall(n)
+		}
+	}, nil
+}
+
+// limitNavigation reserves a navigation for the session and the principal.
+func (c *MCPConn) limitNavigation() error {
+	limiters := c.limiters()
+	for i, l := range limiters {
+		if err := l.navigation(); err != nil {
+			for _, ll := range limiters[:i] {
+				ll.cancelNavigation()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// limitersSweep is the minimum interval between the removals of the idle
+// principals' limiters.
+const limitersSweep = time.Minute
+
+// principalLimiter returns the limiter shared by the principal's sessions.
+func (s *MCPServer) principalLimiter(name string) *limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.Sub(s.limiterswept) >= limitersSweep {
+		s.limiterswept = now
+		maps.DeleteFunc(s.limiters, func(_ string, l *limiter) bool {
+			return l.idle(now)
+		})
+	}
+
+	l, ok := s.limiters[name]
+	if !ok {
+		l = newLimiter(s.principalLimits)
+		s.limiters[name] = l
+	}
+	return l
+}
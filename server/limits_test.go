@@ -0,0 +1,101 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(Limits{CallsPerMinute: 2, ConcurrentCalls: 1, MaxBytes: 10, BytesPeriod: time.Hour})
+
+	var rerr *RateLimitError
+	if err := l.acquireCall(); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if err := l.acquireCall(); !errors.As(err, &rerr) || rerr.Limit != limitConcurrent {
+		t.Errorf("concurrent: got %v", err)
+	}
+	l.releaseCall(4)
+
+	if err := l.acquireCall(); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	l.releaseCall(6)
+
+	if err := l.acquireCall(); !errors.As(err, &rerr) || rerr.Limit != limitBytes || rerr.RetryAfter <= 0 {
+		t.Errorf("bytes: got %v", err)
+	}
+
+	// the bytes are reset after the period.
+	l.period = l.period.Add(-time.Hour)
+	if err := l.acquireCall(); !errors.As(err, &rerr) || rerr.Limit != limitCalls {
+		t.Errorf("calls: got %v", err)
+	}
+	if l.bytes != 0 {
+		t.Errorf("bytes not reset: %d", l.bytes)
+	}
+}
+
+func TestLimiterIdle(t *testing.T) {
+	l := newLimiter(Limits{CallsPerMinute: 60, MaxBytes: 10, BytesPeriod: time.Hour})
+	now := time.Now()
+	if !l.idle(now) {
+		t.Error("new limiter not idle")
+	}
+
+	if err := l.acquireCall(); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if l.idle(now) {
+		t.Error("limiter with a call in flight idle")
+	}
+	l.releaseCall(1)
+	if l.idle(time.Now()) {
+		t.Error("limiter with bytes idle")
+	}
+	if !l.idle(time.Now().Add(time.Hour)) {
+		t.Error("limiter not idle after the period")
+	}
+}
+
+func TestLimitNavigationRefund(t *testing.T) {
+	srv := New(context.Background(), WithLimits(
+		Limits{NavigationsPerMinute: 2},
+		Limits{NavigationsPerMinute: 1},
+	))
+	c := srv.newConn(func(string, any) error { return nil }, Principal{Name: "key:alice"})
+
+	if err := c.limitNavigation(); err != nil {
+		t.Fatalf("navigation: %v", err)
+	}
+
+	var rerr *RateLimitError
+	if err := c.limitNavigation(); !errors.As(err, &rerr) || rerr.Limit != limitNavigations {
+		t.Fatalf("principal limit: got %v", err)
+	}
+
+	// the session's token is refunded when the principal's limit rejects
+	// the navigation.
+	c.limiter.mu.Lock()
+	tokens := c.limiter.navs.tokens
+	c.limiter.mu.Unlock()
+	if tokens < 1 {
+		t.Errorf("session tokens: got %f, want 1", tokens)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+// DefaultLogLevel is the level of the logs sent to the client until it
+// calls logging/setLevel.
+const DefaultLogLevel = slog.LevelWarn
+
+// The logger name used in the log notifications.
+const loggerName = "gomcp"
+
+var ErrLogLevel = errors.New("invalid log level")
+
+// SetLogLevel sets the minimum level of the logs sent to the client.
+func (c *MCPConn) SetLogLevel(level string) error {
+	l, err := slogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	c.loglevel.Set(l)
+	return nil
+}
+
+// Logger returns the connection's logger.
+// The records are written to the default logger and sent to the client as
+// log notifications depending on the connection's log level.
+func (c *MCPConn) Logger() *slog.Logger {
+	return c.logger
+}
+
+// slogLevel converts a MCP log level into a slog level.
+func slogLevel(level string) (slog.Level, error) {
+	switch level {
+	case mcp.LevelDebug:
+		return slog.LevelDebug, nil
+	case mcp.LevelInfo:
+		return slog.LevelInfo, nil
+	case mcp.LevelNotice:
+		return slog.LevelInfo + 2, nil
+	case mcp.LevelWarning:
+		return slog.LevelWarn, nil
+	case mcp.LevelError:
+		return slog.LevelError, nil
+	case mcp.LevelCritical:
+		return slog.LevelError + 4, nil
+	case mcp.LevelAlert:
+		return slog.LevelError + 8, nil
+	case mcp.LevelEmergency:
+		return slog.LevelError + 12, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrLogLevel, level)
+}
+
+// mcpLevel converts a slog level into a MCP log level.
+func mcpLevel(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return mcp.LevelDebug
+	case l < slog.LevelInfo+2:
+		return mcp.LevelInfo
+	case l < slog.LevelWarn:
+		return mcp.LevelNotice
+	case l < slog.LevelError:
+		return mcp.LevelWarning
+	case l < slog.LevelError+4:
+		return mcp.LevelError
+	case l < slog.LevelError+8:
+		return mcp.LevelCritical
+	case l < slog.LevelError+12:
+		return mcp.LevelAlert
+	}
+
+	return mcp.LevelEmergency
+}
+
+// connHandler is a slog handler sending the records to the client as log
+// notifications.
+type connHandler struct {
+	conn   *MCPConn
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *connHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.conn.loglevel.Level()
+}
+
+func (h *connHandler) Handle(_ context.Context, r slog.Record) error {
+	data := map[string]any{"msg": r.Message}
+
+	for _, a := range h.attrs {
+		addAttr(data, a)
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for _, a := range inGroups(h.groups, attrs) {
+		addAttr(data, a)
+	}
+
+	err := h.conn.Notify(mcp.NotificationsMessageMethod, mcp.NotificationsMessage{
+		Level:  mcpLevel(r.Level),
+		Logger: loggerName,
+		Data:   data,
+	})
+	if err != nil {
+		// don't use the conn logger to avoid a loop.
+		slog.Debug("send log", slog.Any("err", err))
+	}
+
+	return nil
+}
+
+// inGroups nests the attributes into the groups.
+func inGroups(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// addAttr adds the attribute to the map, the groups are merged in nested
+// maps.
+func addAttr(m map[string]any, a slog.Attr) {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		g, ok := m[a.Key].(map[string]any)
+		if !ok {
+			g = map[string]any{}
+			m[a.Key] = g
+		}
+		for _, aa := range v.Group() {
+			addAttr(g, aa)
+		}
+	case slog.KindAny:
+		// errors are not json encodable.
+		if err, ok := v.Any().(error); ok {
+			m[a.Key] = err.Error()
+			return
+		}
+		m[a.Key] = v.Any()
+	default:
+		m[a.Key] = v.Any()
+	}
+}
+
+func (h *connHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hh := *h
+	hh.attrs = append(hh.attrs[:len(hh.attrs):len(hh.attrs)], inGroups(h.groups, attrs)...)
+	return &hh
+}
+
+func (h *connHandler) WithGroup(name string) slog.Handler {
+	hh := *h
+	hh.groups = append(hh.groups[:len(hh.groups):len(hh.groups)], name)
+	return &hh
+}
+
+// teeHandler sends the records to several handlers.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	tt := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		tt = append(tt, h.WithAttrs(attrs))
+	}
+	return tt
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	tt := make(teeHandler, 0, len(t))
+	for _, h := range t {
+		tt = append(tt, h.WithGroup(name))
+	}
+	return tt
+}
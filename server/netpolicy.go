@@ -0,0 +1,256 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrURLBlocked = errors.New("url blocked")
+
+// resolveTTL is the duration the resolved addresses are cached.
+const resolveTTL = time.Minute
+
+// resolveTimeout bounds the resolution of a host.
+const resolveTimeout = 10 * time.Second
+
+// cgnat is the shared address space, not covered by netip.Addr.IsPrivate.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// A NetPolicy restricts the URLs loaded by the browser, the navigations,
+// their redirects and the subresources.
+//
+// The rules are either host globs like *.example.com or IP ranges in the CIDR
+// notation like 10.0.0.0/8 or single IPs. The IP rules match the addresses
+// the host resolves to.
+// A URL is blocked if its scheme isn't allowed, if it matches a deny rule, if
+// allow rules are set and it matches none of them, or if it resolves to a
+// private address, unless AllowPrivate is set or an allow IP rule contains
+// the address.
+//
+// The browser resolves the hosts itself, so the policy doesn't prevent DNS
+// rebinding attacks.
+type NetPolicy struct {
+	// Schemes are the allowed URL schemes.
+	Schemes []string
+	// AllowPrivate allows the loopback, private, link-local and unspecified
+	// addresses.
+	AllowPrivate bool
+
+	allow []netRule
+	deny  []netRule
+
+	mu    sync.Mutex
+	addrs map[string]resolved
+}
+
+type resolved struct {
+	addrs  []netip.Addr
+	expire time.Time
+}
+
+// NewNetPolicy returns a policy allowing the http and https schemes with the
+// allow and deny rules.
+func NewNetPolicy(allow, deny []string) (*NetPolicy, error) {
+	p := &NetPolicy{
+		Schemes: []string{"http", "https"},
+		addrs:   make(map[string]resolved),
+	}
+
+	var err error
+	if p.allow, err = parseNetRules(allow); err != nil {
+		return nil, fmt.Errorf("allow: %w", err)
+	}
+	if p.deny, err = parseNetRules(deny); err != nil {
+		return nil, fmt.Errorf("deny: %w", err)
+	}
+
+	return p, nil
+}
+
+// DefaultNetPolicy returns the policy used by default: only http and https
+// URLs resolving to public addresses are allowed.
+func DefaultNetPolicy() *NetPolicy {
+	p, _ := NewNetPolicy(nil, nil)
+	return p
+}
+
+// Check returns an error wrapping ErrURLBlocked if the policy blocks the URL.
+func (p *NetPolicy) Check(ctx context.Context, rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrURLBlocked, rawurl, err)
+	}
+
+	if !slices.Contains(p.Schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: %s: scheme %q not allowed", ErrURLBlocked, rawurl, u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: %s: no host", ErrURLBlocked, rawurl)
+	}
+
+	// check the host globs before the resolution.
+	if r, ok := matchNetRules(p.deny, host, nil); ok {
+		return fmt.Errorf("%w: %s: denied by %s", ErrURLBlocked, rawurl, r)
+	}
+
+	addrs, err := p.resolve(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrURLBlocked, rawurl, err)
+	}
+
+	if r, ok := matchNetRules(p.deny, host, addrs); ok {
+		return fmt.Errorf("%w: %s: denied by %s", ErrURLBlocked, rawurl, r)
+	}
+
+	_, allowed := matchNetRules(p.allow, host, addrs)
+	if len(p.allow) > 0 && !allowed {
+		return fmt.Errorf("%w: %s: not allowed", ErrURLBlocked, rawurl)
+	}
+
+	if p.AllowPrivate {
+		return nil
+	}
+
+	// only the IP rules allow a private address, a host glob can resolve to
+	// anything.
+	for _, a := range addrs {
+		if isPrivate(a) && !containsAddr(p.allow, a) {
+			return fmt.Errorf("%w: %s: %s is a private address", ErrURLBlocked, rawurl, a)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the addresses of the host, cached for resolveTTL.
+func (p *NetPolicy) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if a, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{a.Unmap()}, nil
+	}
+
+	p.mu.Lock()
+	r, ok := p.addrs[host]
+	p.mu.Unlock()
+	if ok && time.Now().Before(r.expire) {
+		return r.addrs, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for i, a := range addrs {
+		addrs[i] = a.Unmap()
+	}
+
+	p.mu.Lock()
+	p.addrs[host] = resolved{addrs: addrs, expire: time.Now().Add(resolveTTL)}
+	p.mu.Unlock()
+
+	return addrs, nil
+}
+
+// isPrivate returns true for the addresses not reachable on the internet.
+func isPrivate(a netip.Addr) bool {
+	return a.IsLoopback() || a.IsPrivate() || a.IsLinkLocalUnicast() ||
+		a.IsLinkLocalMulticast() || a.IsInterfaceLocalMulticast() ||
+		a.IsUnspecified() || cgnat.Contains(a)
+}
+
+// A netRule is either a host glob or an IP prefix.
+type netRule struct {
+	raw    string
+	glob   string
+	prefix netip.Prefix
+}
+
+func (r netRule) String() string {
+	return r.raw
+}
+
+func parseNetRules(rules []string) ([]netRule, error) {
+	parsed := make([]netRule, 0, len(rules))
+	for _, raw := range rules {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		r := netRule{raw: raw}
+		switch {
+		case strings.Contains(raw, "/"):
+			prefix, err := netip.ParsePrefix(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %w", raw, err)
+			}
+			r.prefix = prefix.Masked()
+		default:
+			if a, err := netip.ParseAddr(raw); err == nil {
+				r.prefix = netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen())
+				break
+			}
+			if _, err := path.Match(raw, ""); err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %w", raw, err)
+			}
+			r.glob = strings.ToLower(raw)
+		}
+
+		parsed = append(parsed, r)
+	}
+
+	return parsed, nil
+}
+
+// containsAddr returns true if an IP rule contains the address.
+func containsAddr(rules []netRule, a netip.Addr) bool {
+	return slices.ContainsFunc(rules, func(r netRule) bool {
+		return r.glob == "" && r.prefix.Contains(a)
+	})
+}
+
+// matchNetRules returns the first rule matching the host or one of its
+// addresses.
+func matchNetRules(rules []netRule, host string, addrs []netip.Addr) (netRule, bool) {
+	for _, r := range rules {
+		if r.glob != "" {
+			if ok, _ := path.Match(r.glob, host); ok {
+				return r, true
+			}
+			continue
+		}
+
+		if slices.ContainsFunc(addrs, r.prefix.Contains) {
+			return r, true
+		}
+	}
+
+	return netRule{}, false
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNetPolicyCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		allow   []string
+		deny    []string
+		private bool
+		url     string
+		blocked bool
+	}{
+		{name: "public", url: "http://93.184.215.14/"},
+		{name: "scheme", url: "file:///etc/passwd", blocked: true},
+		{name: "no host", url: "http:///path", blocked: true},
+		{name: "loopback", url: "http://127.0.0.1:8080/", blocked: true},
+		{name: "loopback v6", url: "http://[::1]/", blocked: true},
+		{name: "mapped v4", url: "http://[::ffff:127.0.0.1]/", blocked: true},
+		{name: "private", url: "http://10.1.2.3/", blocked: true},
+		{name: "link local", url: "http://169.254.169.254/latest/meta-data", blocked: true},
+		{name: "cgnat", url: "http://100.64.0.1/", blocked: true},
+		{name: "unspecified", url: "http://0.0.0.0/", blocked: true},
+		{name: "localhost", url: "http://localhost:8080/", blocked: true},
+		{name: "allow private", private: true, url: "http://10.1.2.3/"},
+		{name: "deny glob", deny: []string{"*.example.com"}, url: "https://www.example.com/", blocked: true},
+		{name: "deny glob case", deny: []string{"*.example.com"}, url: "https://WWW.Example.com/", blocked: true},
+		{name: "deny cidr", deny: []string{"93.184.0.0/16"}, url: "http://93.184.215.14/", blocked: true},
+		{name: "allow cidr", allow: []string{"93.184.0.0/16"}, url: "http://93.184.215.14/"},
+		{name: "not allowed", allow: []string{"93.184.0.0/16"}, url: "http://1.1.1.1/", blocked: true},
+		{name: "allow private cidr", allow: []string{"10.0.0.0/8"}, url: "http://10.1.2.3/"},
+		{name: "allow private ip", allow: []string{"127.0.0.1"}, url: "http://127.0.0.1/"},
+		{name: "allow glob private", allow: []string{"localhost"}, url: "http://localhost:8080/", blocked: true},
+		{name: "allow glob private ip", allow: []string{"*"}, url: "http://169.254.169.254/", blocked: true},
+		{name: "deny wins", allow: []string{"10.0.0.0/8"}, deny: []string{"10.1.0.0/16"}, url: "http://10.1.2.3/", blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewNetPolicy(tc.allow, tc.deny)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			p.AllowPrivate = tc.private
+
+			err = p.Check(context.Background(), tc.url)
+			if blocked := errors.Is(err, ErrURLBlocked); blocked != tc.blocked {
+				t.Errorf("blocked: got %v, want %v: %v", blocked, tc.blocked, err)
+			}
+		})
+	}
+}
+
+func TestParseNetRules(t *testing.T) {
+	for _, tc := range []struct {
+		rule string
+		err  bool
+	}{
+		{rule: "*.example.com"},
+		{rule: "10.0.0.0/8"},
+		{rule: "::1"},
+		{rule: "10.0.0.0/33", err: true},
+		{rule: "[a-", err: true},
+	} {
+		if _, err := parseNetRules([]string{tc.rule}); (err != nil) != tc.err {
+			t.Errorf("%s: got %v", tc.rule, err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wellKnownResource is the path of the protected resource metadata.
+const wellKnownResource = "/.well-known/oauth-protected-resource"
+
+// jwksRefresh is the minimum interval between two JWKS fetches.
+const jwksRefresh = time.Minute
+
+// OAuthConfig configures the validation of the OAuth access tokens.
+type OAuthConfig struct {
+	// Resource is the canonical URL of the MCP server, like
+	// https://mcp.example.com.
+	Resource string
+	// Issuer is the authorization server's issuer URL.
+	Issuer string
+	// Audience is the expected audience of the tokens, Resource by default.
+	Audience string
+	// JWKS is the path or the http(s) URL of the issuer's JSON web key set.
+	JWKS string
+	// Scopes maps the scopes to the names of the tools they allow, * allows
+	// all the tools. The tools are not restricted if empty.
+	Scopes map[string][]string
+}
+
+// ProtectedResourceMetadata is the RFC 9728 metadata of the server.
+type ProtectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+}
+
+// OAuth authenticates the requests with the JWT access tokens of an OAuth
+// authorization server.
+type OAuth struct {
+	cfg    OAuthConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewOAuth returns an OAuth authenticator, the JWKS is loaded immediately.
+func NewOAuth(cfg OAuthConfig) (*OAuth, error) {
+	if cfg.Resource == "" {
+		return nil, errors.New("oauth: missing resource")
+	}
+	if _, err := url.Parse(cfg.Resource); err != nil {
+		return nil, fmt.Errorf("oauth: resource: %w", err)
+	}
+	if cfg.JWKS == "" {
+		return nil, errors.New("oauth: missing jwks")
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = cfg.Resource
+	}
+
+	o := &OAuth{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if err := o.loadKeys(); err != nil {
+		return nil, fmt.Errorf("oauth: %w", err)
+	}
+
+	return o, nil
+}
+
+// loadKeys reads or fetches the JWKS.
+func (o *OAuth) loadKeys() error {
+	var b []byte
+	var err error
+	if strings.HasPrefix(o.cfg.JWKS, "https://") || strings.HasPrefix(o.cfg.JWKS, "http://") {
+		b, err = o.fetch(o.cfg.JWKS)
+	} else {
+		b, err = os.ReadFile(o.cfg.JWKS)
+	}
+	if err != nil {
+		return fmt.Errorf("load jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.keys = keys
+	o.fetchedAt = time.Now()
+	o.mu.Unlock()
+
+	return nil
+}
+
+func (o *OAuth) fetch(rawurl string) ([]byte, error) {
+	res, err := o.client.Get(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+// key returns the key by id, the JWKS is reloaded for unknown ids to
+// follow the keys rotation.
+func (o *OAuth) key(kid string) (crypto.PublicKey, bool) {
+	o.mu.Lock()
+	k, ok := o.keys[kid]
+	refresh := !ok && time.Since(o.fetchedAt) > jwksRefresh
+	o.mu.Unlock()
+
+	if !refresh {
+		return k, ok
+	}
+
+	if err := o.loadKeys(); err != nil {
+		slog.Error("oauth", slog.Any("err", err))
+		return nil, false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	k, ok = o.keys[kid]
+	return k, ok
+}
+
+func (o *OAuth) Authenticate(req *http.Request) (Principal, error) {
+	token := bearerToken(req)
+	if token == "" {
+		return Principal{}, fmt.Errorf("%w: missing bearer token", ErrUnauthorized)
+	}
+
+	header, claims, signed, sig, err := parseJWT(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if err := header.validate(); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	key, ok := o.key(header.Kid)
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: %w: unknown key %q", ErrUnauthorized, ErrInvalidToken, header.Kid)
+	}
+
+	if err := verifyJWT(header.Alg, key, signed, sig); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	if err := claims.validate(o.cfg.Issuer, o.cfg.Audience, time.Now()); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	scopes := claims.scopes()
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return Principal{Name: "oauth:" + claims.Subject, Scopes: scopes}, nil
+}
+
+// Metadata returns the protected resource metadata.
+func (o *OAuth) Metadata() ProtectedResourceMetadata {
+	md := ProtectedResourceMetadata{
+		Resource:               o.cfg.Resource,
+		BearerMethodsSupported: []string{"header"},
+	}
+	if o.cfg.Issuer != "" {
+		md.AuthorizationServers = []string{o.cfg.Issuer}
+	}
+	for scope := range o.cfg.Scopes {
+		md.ScopesSupported = append(md.ScopesSupported, scope)
+	}
+	slices.Sort(md.ScopesSupported)
+
+	return md
+}
+
+// MetadataURL returns the URL of the protected resource metadata: the well
+// known path is inserted between the resource's host and path.
+func (o *OAuth) MetadataURL() string {
+	u, err := url.Parse(o.cfg.Resource)
+	if err != nil {
+		return ""
+	}
+
+	u.Path = wellKnownResource + strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// challenge returns the WWW-Authenticate header for the error.
+func (o *OAuth) challenge(err error) string {
+	c := fmt.Sprintf(`Bearer resource_metadata=%q`, o.MetadataURL())
+	if errors.Is(err, ErrInvalidToken) {
+		c += fmt.Sprintf(`, error="invalid_token", error_description=%q`, err.Error())
+	}
+	return c
+}
+
+// insufficientScope returns the WWW-Authenticate header for a tool call not
+// allowed by the token's scopes.
+func (o *OAuth) insufficientScope(tool string) string {
+	return fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q, resource_metadata=%q`,
+		strings.Join(o.scopesFor(tool), " "), o.MetadataURL())
+}
+
+// scopesFor returns the scopes allowing the tool.
+func (o *OAuth) scopesFor(tool string) []string {
+	var scopes []string
+	for scope, tools := range o.cfg.Scopes {
+		if slices.Contains(tools, tool) || slices.Contains(tools, "*") {
+			scopes = append(scopes, scope)
+		}
+	}
+	slices.Sort(scopes)
+
+	return scopes
+}
+
+// Allows returns true if the principal's scopes allow the tool.
+// The principals w/o scopes, not authenticated by OAuth, are allowed.
+func (o *OAuth) Allows(p Principal, tool string) bool {
+	if p.Scopes == nil || len(o.cfg.Scopes) == 0 {
+		return true
+	}
+
+	for _, scope := range o.scopesFor(tool) {
+		if slices.Contains(p.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *OAuth) serveMetadata(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(o.Metadata()); err != nil {
+		slog.Error("oauth metadata", slog.Any("err", err))
+	}
+}
+
+// ParseScopes parses the scopes to tools mapping in the format
+// scope=tool,tool;scope=*.
+func ParseScopes(s string) (map[string][]string, error) {
+	scopes := make(map[string][]string)
+	for _, v := range strings.Split(s, ";") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		scope, tools, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(scope) == "" {
+			return nil, fmt.Errorf("invalid scope %q: expected scope=tool,tool", v)
+		}
+
+		for _, t := range strings.Split(tools, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				scopes[strings.TrimSpace(scope)] = append(scopes[strings.TrimSpace(scope)], t)
+			}
+		}
+	}
+
+	return scopes, nil
+}
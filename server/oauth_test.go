@@ -0,0 +1,311 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testIssuer is an authorization server serving its JWKS.
+type testIssuer struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]*ecdsa.PrivateKey
+	fetches int
+}
+
+func newTestIssuer(t *testing.T, kids ...string) *testIssuer {
+	t.Helper()
+
+	iss := &testIssuer{}
+	iss.rotate(t, kids...)
+
+	iss.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+		iss.fetches++
+
+		size := 32
+		var keys []jwk
+		for kid, k := range iss.keys {
+			keys = append(keys, jwk{
+				Kty: "EC",
+				Kid: kid,
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+				Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys}) // nolint:errcheck
+	}))
+	t.Cleanup(iss.srv.Close)
+
+	return iss
+}
+
+// rotate replaces the issuer's keys.
+func (iss *testIssuer) rotate(t *testing.T, kids ...string) {
+	t.Helper()
+
+	keys := make(map[string]*ecdsa.PrivateKey, len(kids))
+	for _, kid := range kids {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[kid] = k
+	}
+
+	iss.mu.Lock()
+	iss.keys = keys
+	iss.mu.Unlock()
+}
+
+// token returns a ES256 token signed by the key kid, the claims override
+// the default valid claims.
+func (iss *testIssuer) token(t *testing.T, kid, typ string, claims map[string]any) string {
+	t.Helper()
+
+	iss.mu.Lock()
+	key := iss.keys[kid]
+	iss.mu.Unlock()
+	if key == nil {
+		// sign with an unknown key.
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := map[string]any{
+		"iss":   iss.srv.URL,
+		"sub":   "alice",
+		"aud":   "https://mcp.example.com/mcp",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "read",
+	}
+	for k, v := range claims {
+		c[k] = v
+	}
+
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": "ES256", "kid": kid, "typ": typ}) + "." + enc(c)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signJWT(t, "ES256", key, []byte(signed)))
+}
+
+// fetched returns the number of JWKS fetches.
+func (iss *testIssuer) fetched() int {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	return iss.fetches
+}
+
+func (iss *testIssuer) oauth(t *testing.T) *OAuth {
+	t.Helper()
+
+	o, err := NewOAuth(OAuthConfig{
+		Resource: "https://mcp.example.com/mcp",
+		Issuer:   iss.srv.URL,
+		JWKS:     iss.srv.URL + "/jwks",
+		Scopes:   map[string][]string{"read": {"markdown"}, "admin": {"*"}},
+	})
+	if err != nil {
+		t.Fatalf("new oauth: %v", err)
+	}
+	return o
+}
+
+func bearerRequest(method, target, token string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestOAuthAuthenticate(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	o := iss.oauth(t)
+
+	p, err := o.Authenticate(bearerRequest("GET", "/sse", iss.token(t, "k1", "at+jwt", nil)))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if p.Name != "oauth:alice" || !slices.Equal(p.Scopes, []string{"read"}) {
+		t.Errorf("principal: got %+v", p)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		reason string
+	}{
+		{"no token", "", "missing bearer token"},
+		{"malformed", "abc", "malformed"},
+		{"unknown key", iss.token(t, "k2", "JWT", nil), "unknown key"},
+		{"token type", iss.token(t, "k1", "dpop+jwt", nil), "unexpected typ"},
+		{"expired", iss.token(t, "k1", "JWT", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}), "expired"},
+		{"issuer", iss.token(t, "k1", "JWT", map[string]any{"iss": "https://other.example.com"}), "unexpected issuer"},
+		{"audience", iss.token(t, "k1", "JWT", map[string]any{"aud": "https://other.example.com"}), "unexpected audience"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := o.Authenticate(bearerRequest("GET", "/sse", tc.token))
+			if !errors.Is(err, ErrUnauthorized) || !strings.Contains(err.Error(), tc.reason) {
+				t.Errorf("got %v, want %s", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestOAuthKeyRotation(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	o := iss.oauth(t)
+
+	iss.rotate(t, "k2")
+	token := iss.token(t, "k2", "JWT", nil)
+
+	// the JWKS isn't fetched again before jwksRefresh.
+	if _, err := o.Authenticate(bearerRequest("GET", "/sse", token)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("before refresh: got %v", err)
+	}
+	if n := iss.fetched(); n != 1 {
+		t.Errorf("fetches: got %d, want 1", n)
+	}
+
+	// an unknown key triggers a refetch after jwksRefresh.
+	o.mu.Lock()
+	o.fetchedAt = o.fetchedAt.Add(-jwksRefresh)
+	o.mu.Unlock()
+
+	if _, err := o.Authenticate(bearerRequest("GET", "/sse", token)); err != nil {
+		t.Errorf("after refresh: got %v", err)
+	}
+	if n := iss.fetched(); n != 2 {
+		t.Errorf("fetches: got %d, want 2", n)
+	}
+
+	// the rotated key is removed.
+	if _, err := o.Authenticate(bearerRequest("GET", "/sse", iss.token(t, "k1", "JWT", nil))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("old key: got %v", err)
+	}
+}
+
+func TestOAuthHandler(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	srv := New(context.Background(), WithOAuth(iss.oauth(t)))
+	h := srv.Handler()
+
+	metadata := "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"
+
+	t.Run("challenge", func(t *testing.T) {
+		for _, tc := range []struct {
+			name  string
+			token string
+			want  string
+		}{
+			{"no token", "", `Bearer resource_metadata="` + metadata + `"`},
+			{"invalid token", iss.token(t, "k1", "JWT", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}),
+				`Bearer resource_metadata="` + metadata + `", error="invalid_token", error_description=`},
+		} {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, bearerRequest("GET", "/sse", tc.token))
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s: got status %d", tc.name, w.Code)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, tc.want) {
+				t.Errorf("%s: got challenge %s, want %s", tc.name, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", wellKnownResource+"/mcp", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d", w.Code)
+		}
+		var md ProtectedResourceMetadata
+		if err := json.NewDecoder(w.Body).Decode(&md); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		want := ProtectedResourceMetadata{
+			Resource:               "https://mcp.example.com/mcp",
+			AuthorizationServers:   []string{iss.srv.URL},
+			ScopesSupported:        []string{"admin", "read"},
+			BearerMethodsSupported: []string{"header"},
+		}
+		if md.Resource != want.Resource ||
+			!slices.Equal(md.AuthorizationServers, want.AuthorizationServers) ||
+			!slices.Equal(md.ScopesSupported, want.ScopesSupported) ||
+			!slices.Equal(md.BearerMethodsSupported, want.BearerMethodsSupported) {
+			t.Errorf("got %+v, want %+v", md, want)
+		}
+	})
+
+	t.Run("insufficient scope", func(t *testing.T) {
+		token := iss.token(t, "k1", "JWT", nil)
+		p, err := srv.oauth.Authenticate(bearerRequest("GET", "/sse", token))
+		if err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+
+		sessions := NewSessions()
+		s := NewSession()
+		s.principal = p.Name
+		sessions.Add(s)
+		defer s.Close()
+
+		handler := authenticate(srv.oauth, handleMessage(sessions, srv))
+		call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"goto","arguments":{"url":"https://example.com"}}}`
+
+		req := httptest.NewRequest("POST", "/messages?id="+s.id.String(), strings.NewReader(call))
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("got status %d: %s", w.Code, w.Body)
+		}
+		want := `Bearer error="insufficient_scope", scope="admin", resource_metadata="` + metadata + `"`
+		if got := w.Header().Get("WWW-Authenticate"); got != want {
+			t.Errorf("got challenge %s, want %s", got, want)
+		}
+	})
+}
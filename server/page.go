@@ -0,0 +1,284 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// A link of the page.
+type Link struct {
+	URL  string `json:"url"`
+	Text string `json:"text,omitempty"`
+}
+
+// parseLinks returns the links with a href from the document.
+func parseLinks(html string) ([]Link, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	links := []Link{}
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		links = append(links, Link{
+			URL:  href,
+			Text: normalizeSpace(a.Text()),
+		})
+	})
+
+	return links, nil
+}
+
+// General information about the page.
+type PageInfo struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Canonical   string `json:"canonical,omitempty"`
+	Links       int    `json:"links"`
+	Forms       int    `json:"forms"`
+}
+
+// parsePageInfo returns the page information found in the document.
+// The URL is not contained in the document and must be set by the caller.
+func parsePageInfo(html string) (PageInfo, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("parse html: %w", err)
+	}
+
+	description, _ := doc.Find(`meta[name="description"]`).First().Attr("content")
+	lang, _ := doc.Find("html").First().Attr("lang")
+	canonical, _ := doc.Find(`link[rel="canonical"]`).First().Attr("href")
+
+	return PageInfo{
+		Title:       normalizeSpace(doc.Find("title").First().Text()),
+		Description: normalizeSpace(description),
+		Language:    lang,
+		Canonical:   canonical,
+		Links:       doc.Find("a[href]").Length(),
+		Forms:       doc.Find("form").Length(),
+	}, nil
+}
+
+func (p PageInfo) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "URL: %s\nTitle: %s\n", p.URL, p.Title)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", p.Description)
+	}
+	if p.Language != "" {
+		fmt.Fprintf(&b, "Language: %s\n", p.Language)
+	}
+	if p.Canonical != "" {
+		fmt.Fprintf(&b, "Canonical: %s\n", p.Canonical)
+	}
+	fmt.Fprintf(&b, "Links: %d\nForms: %d\n", p.Links, p.Forms)
+
+	return b.String()
+}
+
+// A form of the page.
+type Form struct {
+	ID     string      `json:"id,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	Action string      `json:"action,omitempty"`
+	Method string      `json:"method"`
+	Fields []FormField `json:"fields"`
+}
+
+// A field of a form: an input, a select, a textarea or a button.
+type FormField struct {
+	Tag         string   `json:"tag"`
+	Type        string   `json:"type,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	ID          string   `json:"id,omitempty"`
+	Label       string   `json:"label,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Value       string   `json:"value,omitempty"`
+	Required    bool     `json:"required"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// title returns a short description of the form for the user.
+func (f Form) title() string {
+	switch {
+	case f.Name != "":
+		return f.Name
+	case f.ID != "":
+		return f.ID
+	case f.Action != "":
+		return "to " + f.Action
+	}
+	return "of the page"
+}
+
+// missing returns the empty required fields not set by the form nor given
+// in values, the passwords excepted.
+func (f Form) missing(values map[string]string) []FormField {
+	return f.emptyFields(values, func(field FormField) bool {
+		return field.Required && field.Type != "password"
+	})
+}
+
+// passwords returns the empty password fields not given in values.
+func (f Form) passwords(values map[string]string) []FormField {
+	return f.emptyFields(values, func(field FormField) bool {
+		return field.Type == "password"
+	})
+}
+
+// emptyFields returns the fields matching keep w/o value in the form nor in
+// values.
+func (f Form) emptyFields(values map[string]string, keep func(FormField) bool) []FormField {
+	var fields []FormField
+	for _, field := range f.Fields {
+		if field.Name == "" || field.Value != "" || values[field.Name] != "" {
+			continue
+		}
+		switch field.Type {
+		case "submit", "button", "reset", "image":
+			continue
+		}
+		if field.Tag == "button" {
+			continue
+		}
+
+		if keep(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// parseForms returns the forms and their fields from the document.
+func parseForms(html string) ([]Form, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	forms := []Form{}
+	doc.Find("form").Each(func(_ int, f *goquery.Selection) {
+		method := strings.ToUpper(f.AttrOr("method", "GET"))
+
+		form := Form{
+			ID:     f.AttrOr("id", ""),
+			Name:   f.AttrOr("name", ""),
+			Action: f.AttrOr("action", ""),
+			Method: method,
+			Fields: []FormField{},
+		}
+
+		f.Find("input, select, textarea, button").Each(func(_ int, s *goquery.Selection) {
+			tag := goquery.NodeName(s)
+			typ := s.AttrOr("type", "")
+			if typ == "hidden" {
+				return
+			}
+
+			field := FormField{
+				Tag:         tag,
+				Type:        typ,
+				Name:        s.AttrOr("name", ""),
+				ID:          s.AttrOr("id", ""),
+				Placeholder: s.AttrOr("placeholder", ""),
+				Value:       s.AttrOr("value", ""),
+				Required:    s.Is("[required]"),
+				Label:       fieldLabel(doc, s),
+			}
+
+			switch tag {
+			case "select":
+				s.Find("option").Each(func(_ int, o *goquery.Selection) {
+					field.Options = append(field.Options, normalizeSpace(o.Text()))
+				})
+			case "textarea", "button":
+				if field.Value == "" {
+					field.Value = normalizeSpace(s.Text())
+				}
+			}
+
+			form.Fields = append(form.Fields, field)
+		})
+
+		forms = append(forms, form)
+	})
+
+	return forms, nil
+}
+
+// fieldLabel returns the text of the label associated to the field, either
+// by its id or by nesting.
+func fieldLabel(doc *goquery.Document, s *goquery.Selection) string {
+	if id, ok := s.Attr("id"); ok && id != "" {
+		label := doc.Find("label").FilterFunction(func(_ int, l *goquery.Selection) bool {
+			return l.AttrOr("for", "") == id
+		})
+		if label.Length() > 0 {
+			return normalizeSpace(label.First().Text())
+		}
+	}
+
+	if label := s.Closest("label"); label.Length() > 0 {
+		return normalizeSpace(label.Text())
+	}
+
+	return ""
+}
+
+// formatForms returns a readable text rendering of the forms.
+func formatForms(forms []Form) string {
+	if len(forms) == 0 {
+		return "No form found."
+	}
+
+	var b strings.Builder
+	for i, f := range forms {
+		fmt.Fprintf(&b, "Form %d: %s %s", i+1, f.Method, f.Action)
+		if f.ID != "" {
+			fmt.Fprintf(&b, " (id=%s)", f.ID)
+		}
+		b.WriteString("\n")
+
+		for _, fd := range f.Fields {
+			fmt.Fprintf(&b, "  - %s", fd.Tag)
+			if fd.Type != "" {
+				fmt.Fprintf(&b, "[type=%s]", fd.Type)
+			}
+			if fd.Name != "" {
+				fmt.Fprintf(&b, " name=%s", fd.Name)
+			}
+			if fd.Label != "" {
+				fmt.Fprintf(&b, " label=%q", fd.Label)
+			}
+			if fd.Required {
+				b.WriteString(" required")
+			}
+			if len(fd.Options) > 0 {
+				fmt.Fprintf(&b, " options=%s", strings.Join(fd.Options, "|"))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+// A PromptTemplate is a prompt rendered with the client's arguments.
+// Template uses the text/template syntax, the arguments are available by
+// name, like {{.url}}. Missing optional arguments are empty strings.
+type PromptTemplate struct {
+	mcp.Prompt
+	Template string `json:"template"`
+	// Values are the suggested values of the arguments by argument name,
+	// used to complete the arguments.
+	Values map[string][]string `json:"values,omitempty"`
+}
+
+// Render returns the prompt messages for the arguments.
+func (p PromptTemplate) Render(args map[string]string) ([]mcp.PromptMessage, error) {
+	var missing []string
+	for _, a := range p.Arguments {
+		if a.Required && args[a.Name] == "" {
+			missing = append(missing, a.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPromptArgs, strings.Join(missing, ", "))
+	}
+
+	// all declared arguments are defined for the template.
+	data := make(map[string]string, len(p.Arguments))
+	for _, a := range p.Arguments {
+		data[a.Name] = args[a.Name]
+	}
+
+	tpl, err := template.New(p.Name).Option("missingkey=zero").Parse(p.Template)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+
+	return []mcp.PromptMessage{{
+		Role:    mcp.RoleUser,
+		Content: mcp.NewTextContent(b.String()),
+	}}, nil
+}
+
+var (
+	ErrNoPrompt     = errors.New("no prompt found")
+	ErrPromptExists = errors.New("prompt already registered")
+	ErrPromptArgs   = errors.New("missing required arguments")
+)
+
+// RegisterPrompt adds a prompt to the server.
+// It returns ErrPromptExists if a prompt with the same name is already
+// registered.
+func (s *MCPServer) RegisterPrompt(p PromptTemplate) error {
+	if p.Name == "" {
+		return errors.New("prompt without name")
+	}
+
+	// fail early on invalid templates.
+	if _, err := template.New(p.Name).Parse(p.Template); err != nil {
+		return fmt.Errorf("prompt %s: %w", p.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.prompts, func(pp PromptTemplate) bool { return pp.Name == p.Name }) {
+		return fmt.Errorf("%w: %s", ErrPromptExists, p.Name)
+	}
+
+	s.prompts = append(s.prompts, p)
+	return nil
+}
+
+// ListPrompts returns the registered prompts in registration order.
+func (s *MCPServer) ListPrompts() []mcp.Prompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prompts := make([]mcp.Prompt, 0, len(s.prompts))
+	for _, p := range s.prompts {
+		prompts = append(prompts, p.Prompt)
+	}
+
+	return prompts
+}
+
+// prompt returns the registered prompt corresponding to the name.
+func (s *MCPServer) prompt(name string) (PromptTemplate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.prompts, func(p PromptTemplate) bool { return p.Name == name })
+	if i < 0 {
+		return PromptTemplate{}, false
+	}
+
+	return s.prompts[i], true
+}
+
+// GetPrompt renders the prompt with the arguments.
+func (s *MCPServer) GetPrompt(name string, args map[string]string) (mcp.PromptsGetResponse, error) {
+	p, ok := s.prompt(name)
+	if !ok {
+		return mcp.PromptsGetResponse{}, fmt.Errorf("%w: %s", ErrNoPrompt, name)
+	}
+
+	msgs, err := p.Render(args)
+	if err != nil {
+		return mcp.PromptsGetResponse{}, err
+	}
+
+	return mcp.PromptsGetResponse{
+		Description: p.Description,
+		Messages:    msgs,
+	}, nil
+}
+
+// LoadPrompts reads the prompts defined by the JSON files of the directory.
+// Each file contains a PromptTemplate, its name defaults to the file name
+// w/o extension:
+//
+//	{
+//	  "description": "Find the opening hours of a shop.",
+//	  "arguments": [{"name": "shop", "required": true}],
+//	  "template": "Search the opening hours of {{.shop}} and give them.",
+//	  "values": {"shop": ["Lightpanda Coffee", "Lightpanda Books"]}
+//	}
+func LoadPrompts(dir string) ([]PromptTemplate, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list prompts: %w", err)
+	}
+
+	prompts := make([]PromptTemplate, 0, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read prompt: %w", err)
+		}
+
+		var p PromptTemplate
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, fmt.Errorf("decode prompt %s: %w", f, err)
+		}
+
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		}
+
+		prompts = append(prompts, p)
+	}
+
+	return prompts, nil
+}
+
+// builtinPrompts returns the prompts registered by default.
+func builtinPrompts() []PromptTemplate {
+	return []PromptTemplate{
+		{
+			Prompt: mcp.Prompt{
+				Name:        "research",
+				Description: "Research a topic on the web and write a sourced summary.",
+				Arguments: []mcp.PromptArgument{
+					{Name: "topic", Description: "The topic to research.", Required: true},
+					{Name: "sources", Description: "The number of sources to read, 3 by default."},
+				},
+			},
+			Template: "Research the following topic: {{.topic}}\n\n" +
+				"Use the search tool to find relevant pages, then open " +
+				"{{if .sources}}{{.sources}}{{else}}3{{end}} of the most relevant results " +
+				"with the goto tool and read them with the markdown tool.\n" +
+				"Write a concise summary of your findings and list the URLs of the sources you used.",
+		},
+		{
+			Prompt: mcp.Prompt{
+				Name:        "summarize",
+				Description: "Summarize the content of a web page.",
+				Arguments: []mcp.PromptArgument{
+					{Name: "url", Description: "The URL of the page to summarize.", Required: true},
+					{Name: "length", Description: "The expected summary length, like 3 sentences."},
+				},
+			},
+			Template: "Open {{.url}} with the goto tool and read it with the markdown tool.\n" +
+				"Summarize the page content{{if .length}} in {{.length}}{{end}}, " +
+				"keeping the key facts and figures.",
+		},
+		{
+			Prompt: mcp.Prompt{
+				Name:        "extract_table",
+				Description: "Extract a table from a web page as structured data.",
+				Arguments: []mcp.PromptArgument{
+					{Name: "url", Description: "The URL of the page containing the table.", Required: true},
+					{Name: "table", Description: "A description of the table to extract, the first one by default."},
+					{Name: "format", Description: "The output format: csv, json or markdown. Markdown by default."},
+				},
+			},
+			Template: "Open {{.url}} with the goto tool and read it with the markdown tool.\n" +
+				"Extract {{if .table}}the table {{.table}}{{else}}the first table of the page{{end}} " +
+				"and return it in {{if .format}}{{.format}}{{else}}markdown{{end}} format, " +
+				"keeping the header row and all the data rows.",
+			Values: map[string][]string{
+				"format": {"csv", "json", "markdown"},
+			},
+		},
+		{
+			Prompt: mcp.Prompt{
+				Name:        "compare_products",
+				Description: "Compare a product or products across several web sites.",
+				Arguments: []mcp.PromptArgument{
+					{Name: "products", Description: "The product or products to compare.", Required: true},
+					{Name: "sites", Description: "A comma separated list of sites to compare, found with a search by default."},
+					{Name: "criteria", Description: "The comparison criteria, price and availability by default."},
+				},
+			},
+			Template: "Compare {{.products}} " +
+				"{{if .sites}}across the following sites: {{.sites}}{{else}}across the main online shops found with the search tool{{end}}.\n" +
+				"For each site, use the search tool with the site argument or open the product page with the goto tool " +
+				"and read it with the markdown tool.\n" +
+				"Compare them by {{if .criteria}}{{.criteria}}{{else}}price and availability{{end}} " +
+				"in a table with one row per site and include the product page URLs.",
+		},
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+// The page resources are available for the current page with
+// page://current/{kind} and for a specific tab with page://tab/{tabId}/{kind}.
+// The last screenshots captured by the screenshot tool are available with
+// screenshot://{n}.
+const (
+	currentPageURI = "page://current/"
+	tabPageURI     = "page://tab/"
+	screenshotURI  = "screenshot://"
+)
+
+// maxScreenshots is the number of screenshots kept by a connection.
+const maxScreenshots = 20
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+// A screenshot captured by the screenshot tool.
+type screenshot struct {
+	n   int
+	png []byte
+}
+
+// A kind of resource available for each page.
+type pageResource struct {
+	kind        string
+	mimeType    string
+	description string
+	read        func(c *MCPConn) (string, error)
+}
+
+var pageResources = []pageResource{
+	{
+		kind:        "markdown",
+		mimeType:    "text/markdown",
+		description: "The page content in markdown format.",
+		read:        (*MCPConn).GetMarkdown,
+	},
+	{
+		kind:        "html",
+		mimeType:    "text/html",
+		description: "The page HTML.",
+		read:        (*MCPConn).getHTML,
+	},
+	{
+		kind:        "links",
+		mimeType:    "application/json",
+		description: "The page links.",
+		read: func(c *MCPConn) (string, error) {
+			links, err := c.GetLinks()
+			if err != nil {
+				return "", err
+			}
+			return marshal(links)
+		},
+	},
+	{
+		kind:        "info",
+		mimeType:    "application/json",
+		description: "General information about the page.",
+		read: func(c *MCPConn) (string, error) {
+			info, err := c.GetPageInfo()
+			if err != nil {
+				return "", err
+			}
+			return marshal(info)
+		},
+	},
+}
+
+func marshal(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json encode: %w", err)
+	}
+	return string(b), nil
+}
+
+// ResourceTemplates returns the templates of the resources available.
+func ResourceTemplates() []mcp.ResourceTemplate {
+	kinds := make([]string, 0, len(pageResources))
+	for _, r := range pageResources {
+		kinds = append(kinds, r.kind)
+	}
+
+	return []mcp.ResourceTemplate{
+		{
+			URITemplate: tabPageURI + "{tabId}/{kind}",
+			Name:        "tab",
+			Description: "A browser tab page, kind is one of " + strings.Join(kinds, ", ") + ".",
+		},
+		{
+			URITemplate: screenshotURI + "{n}",
+			Name:        "screenshot",
+			Description: "A screenshot captured by the screenshot tool.",
+			MimeType:    "image/png",
+		},
+	}
+}
+
+// ListResources returns the resources available for the connection: the
+// loaded page and the captured screenshots.
+func (c *MCPConn) ListResources() []mcp.Resource {
+	res := []mcp.Resource{}
+
+	if tab := c.tabID(); tab != "" {
+		for _, r := range pageResources {
+			res = append(res, mcp.Resource{
+				URI:         currentPageURI + r.kind,
+				Name:        "current " + r.kind,
+				Description: r.description,
+				MimeType:    r.mimeType,
+			})
+		}
+		for _, r := range pageResources {
+			res = append(res, mcp.Resource{
+				URI:         tabPageURI + tab + "/" + r.kind,
+				Name:        "tab " + tab + " " + r.kind,
+				Description: r.description,
+				MimeType:    r.mimeType,
+			})
+		}
+	}
+
+	c.mu.Lock()
+	for _, s := range c.screenshots {
+		res = append(res, mcp.Resource{
+			URI:      screenshotURI + strconv.Itoa(s.n),
+			Name:     "screenshot " + strconv.Itoa(s.n),
+			MimeType: "image/png",
+		})
+	}
+	c.mu.Unlock()
+
+	return res
+}
+
+// ReadResource returns the content of the resource.
+// It returns ErrResourceNotFound for unknown URIs.
+func (c *MCPConn) ReadResource(uri string) ([]mcp.ResourceContents, error) {
+	if v, ok := strings.CutPrefix(uri, screenshotURI); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrResourceNotFound
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		i := slices.IndexFunc(c.screenshots, func(s screenshot) bool { return s.n == n })
+		if i < 0 {
+			return nil, ErrResourceNotFound
+		}
+
+		return []mcp.ResourceContents{{
+			URI:      uri,
+			MimeType: "image/png",
+			Blob:     base64.StdEncoding.EncodeToString(c.screenshots[i].png),
+		}}, nil
+	}
+
+	// no page loaded.
+	if c.tabID() == "" {
+		return nil, ErrResourceNotFound
+	}
+
+	kind, ok := strings.CutPrefix(uri, currentPageURI)
+	if !ok {
+		v, ok := strings.CutPrefix(uri, tabPageURI)
+		if !ok {
+			return nil, ErrResourceNotFound
+		}
+
+		var tab string
+		tab, kind, ok = strings.Cut(v, "/")
+		if !ok || tab == "" || tab != c.tabID() {
+			return nil, ErrResourceNotFound
+		}
+	}
+
+	for _, r := range pageResources {
+		if r.kind != kind {
+			continue
+		}
+
+		text, err := r.read(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return []mcp.ResourceContents{{
+			URI:      uri,
+			MimeType: r.mimeType,
+			Text:     text,
+		}}, nil
+	}
+
+	return nil, ErrResourceNotFound
+}
+
+// Subscribe registers the client's interest in the resource's updates.
+func (c *MCPConn) Subscribe(uri string) {
+	c.mu.Lock()
+	c.subscriptions[uri] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (c *MCPConn) Unsubscribe(uri string) {
+	c.mu.Lock()
+	delete(c.subscriptions, uri)
+	c.mu.Unlock()
+}
+
+// pageChanged notifies the client the resources changed after a navigation.
+func (c *MCPConn) pageChanged() {
+	c.notify(mcp.NotificationsResourcesListChangedMethod, nil)
+
+	tab := tabPageURI + c.tabID() + "/"
+
+	c.mu.Lock()
+	var uris []string
+	for uri := range c.subscriptions {
+		if strings.HasPrefix(uri, currentPageURI) || strings.HasPrefix(uri, tab) {
+			uris = append(uris, uri)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, uri := range uris {
+		c.notify(mcp.NotificationsResourcesUpdatedMethod, mcp.NotificationsResourcesUpdated{URI: uri})
+	}
+}
+
+// notify sends a notification to the client, errors are only logged.
+func (c *MCPConn) notify(method string, params any) {
+	if err := c.Notify(method, params); err != nil {
+		slog.Debug("notify", slog.String("method", method), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	// robotsTTL is the duration robots.txt files are cached.
+	robotsTTL = 24 * time.Hour
+	// robotsErrorTTL is the duration unreachable robots.txt are cached.
+	robotsErrorTTL = time.Minute
+	// robotsMaxSize is the maximum size of the robots.txt read.
+	robotsMaxSize = 500 << 10
+	// maxPoliteWait is the maximum wait of a request, longer waits fail.
+	maxPoliteWait = 30 * time.Second
+)
+
+// Politeness respects the robots.txt of the sites and spaces the requests
+// to the same host.
+// It's shared by all the sessions of the server.
+type Politeness struct {
+	// UserAgent is the product token matched against the robots.txt
+	// user-agent lines.
+	UserAgent string
+	// MinDelay is the minimum delay between two requests to the same host,
+	// the robots.txt crawl-delay is used if longer.
+	MinDelay time.Duration
+	// ExemptSearch exempts the search engines' results pages from the
+	// robots.txt rules, their requests are still spaced.
+	ExemptSearch bool
+
+	client *http.Client
+	policy *NetPolicy
+
+	mu     sync.Mutex
+	robots map[string]*robotsEntry
+	next   map[string]time.Time
+	// swept is the last eviction of the expired entries.
+	swept time.Time
+}
+
+type robotsEntry struct {
+	rules  robotsRules
+	expire time.Time
+}
+
+func NewPoliteness(userAgent string, minDelay time.Duration) *Politeness {
+	p := &Politeness{
+		UserAgent: userAgent,
+		MinDelay:  minDelay,
+		robots:    make(map[string]*robotsEntry),
+		next:      make(map[string]time.Time),
+	}
+
+	p.client = &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if p.policy != nil {
+				return p.policy.Check(req.Context(), req.URL.String())
+			}
+			return nil
+		},
+	}
+
+	return p
+}
+
+// Wait returns ErrRobotsDisallowed if the robots.txt disallows the URL and
+// waits until the URL's host can be requested.
+func (p *Politeness) Wait(ctx context.Context, rawurl string) error {
+	return p.wait(ctx, rawurl, true)
+}
+
+// Delay waits until the URL's host can be requested w/o checking if the
+// robots.txt allows the URL.
+// It's used for the search engines, their robots.txt usually disallow the
+// search pages.
+func (p *Politeness) Delay(ctx context.Context, rawurl string) error {
+	return p.wait(ctx, rawurl, false)
+}
+
+func (p *Politeness) wait(ctx context.Context, rawurl string, checkRobots bool) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+
+	rules := p.rules(ctx, u)
+	if checkRobots && !rules.allowed(u.EscapedPath(), u.RawQuery) {
+		return fmt.Errorf("%w: %s", ErrRobotsDisallowed, rawurl)
+	}
+
+	delay := max(p.MinDelay, rules.delay)
+	if delay <= 0 {
+		return nil
+	}
+
+	// reserve the next slot of the host.
+	host := strings.ToLower(u.Host)
+	now := time.Now()
+
+	p.mu.Lock()
+	p.sweep(now)
+	at := now
+	if next := p.next[host]; next.After(at) {
+		at = next
+	}
+	if wait := at.Sub(now); wait > maxPoliteWait {
+		p.mu.Unlock()
+		return &RateLimitError{Limit: "crawl_delay", RetryAfter: wait}
+	}
+	p.next[host] = at.Add(delay)
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(at.Sub(now)):
+		return nil
+	}
+}
+
+// sweep evicts the expired entries, at most once per robotsErrorTTL.
+// The lock must be held.
+func (p *Politeness) sweep(now time.Time) {
+	if now.Sub(p.swept) < robotsErrorTTL {
+		return
+	}
+	p.swept = now
+
+	maps.DeleteFunc(p.next, func(_ string, next time.Time) bool {
+		return next.Before(now)
+	})
+	maps.DeleteFunc(p.robots, func(_ string, e *robotsEntry) bool {
+		return e.expire.Before(now)
+	})
+}
+
+// rules returns the robots.txt rules of the URL's origin for the user agent.
+func (p *Politeness) rules(ctx context.Context, u *url.URL) robotsRules {
+	origin := origin(u)
+
+	p.mu.Lock()
+	e, ok := p.robots[origin]
+	p.mu.Unlock()
+	if ok && time.Now().Before(e.expire) {
+		return e.rules
+	}
+
+	rules, ttl := p.fetch(ctx, origin+"/robots.txt")
+
+	p.mu.Lock()
+	p.sweep(time.Now())
+	p.robots[origin] = &robotsEntry{rules: rules, expire: time.Now().Add(ttl)}
+	p.mu.Unlock()
+
+	return rules
+}
+
+// fetch returns the rules of the robots.txt and their cache duration.
+// Following the RFC 9309, everything is allowed if the file is not found
+// and disallowed if it's unreachable.
+func (p *Politeness) fetch(ctx context.Context, rawurl string) (robotsRules, time.Duration) {
+	disallowed := robotsRules{disallow: []string{"/"}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawurl, nil)
+	if err != nil {
+		return disallowed, robotsErrorTTL
+	}
+	req.Header.Set("User-Agent", p.UserAgent)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return disallowed, robotsErrorTTL
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 500:
+		return disallowed, robotsErrorTTL
+	case res.StatusCode >= 400:
+		return robotsRules{}, robotsTTL
+	case res.StatusCode != http.StatusOK:
+		return disallowed, robotsErrorTTL
+	}
+
+	return parseRobots(io.LimitReader(res.Body, robotsMaxSize), p.UserAgent), robotsTTL
+}
+
+// robotsRules are the rules of a robots.txt group.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+	delay    time.Duration
+}
+
+// allowed returns true if the path is allowed: the longest matching rule
+// applies, allow wins on equality.
+func (r robotsRules) allowed(path, query string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if query != "" {
+		path += "?" + query
+	}
+
+	longest := func(patterns []string) int {
+		n := -1
+		for _, p := range patterns {
+			if len(p) > n && robotsMatch(p, path) {
+				n = len(p)
+			}
+		}
+		return n
+	}
+
+	return longest(r.allow) >= longest(r.disallow)
+}
+
+// robotsMatch matches the path against the pattern, * matches any sequence
+// and a trailing $ anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		// the last part must match the end of the anchored patterns.
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path, part)
+		}
+		j := strings.Index(path, part)
+		if j < 0 {
+			return false
+		}
+		path = path[j+len(part):]
+	}
+
+	return !anchored || path == ""
+}
+
+// parseRobots returns the rules of the group matching the user agent, the
+// rules of the * group otherwise.
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	agent := strings.ToLower(userAgent)
+
+	var (
+		matched, star robotsRules
+		hasMatched    bool
+		// the current group's agents.
+		agents  []string
+		inRules bool
+	)
+
+	apply := func(f func(*robotsRules)) {
+		for _, a := range agents {
+			switch {
+			case a == "*":
+				f(&star)
+			case agent != "" && a == agent:
+				f(&matched)
+			}
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// a user-agent line after rules starts a new group.
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			a := strings.ToLower(value)
+			agents = append(agents, a)
+			// an empty group of the agent allows everything.
+			if agent != "" && a == agent {
+				hasMatched = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			apply(func(rr *robotsRules) {
+				if key == "allow" {
+					rr.allow = append(rr.allow, value)
+				} else {
+					rr.disallow = append(rr.disallow, value)
+				}
+			})
+		case "crawl-delay":
+			inRules = true
+			secs, err := strconv.ParseFloat(value, 64)
+			if err != nil || secs < 0 {
+				continue
+			}
+			apply(func(rr *robotsRules) {
+				rr.delay = time.Duration(secs * float64(time.Second))
+			})
+		}
+	}
+
+	if hasMatched {
+		return matched
+	}
+	return star
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The examples of the RFC 9309 and of the Google's robots.txt
+// specification.
+func TestRobotsMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		match   []string
+		nomatch []string
+	}{
+		{
+			pattern: "/",
+			match:   []string{"/", "/fish"},
+		},
+		{
+			pattern: "/fish",
+			match:   []string{"/fish", "/fish.html", "/fish/salmon.html", "/fishheads", "/fishheads/yummy.html", "/fish.php?id=anything"},
+			nomatch: []string{"/Fish.asp", "/catfish", "/?id=fish", "/desert/fish"},
+		},
+		{
+			pattern: "/fish*",
+			match:   []string{"/fish", "/fish.html", "/fish/salmon.html", "/fishheads"},
+			nomatch: []string{"/Fish.asp", "/catfish"},
+		},
+		{
+			pattern: "/fish/",
+			match:   []string{"/fish/", "/fish/?id=anything", "/fish/salmon.htm"},
+			nomatch: []string{"/fish", "/fish.html", "/animals/fish/", "/Fish/Salmon.asp"},
+		},
+		{
+			pattern: "/*.php",
+			match:   []string{"/index.php", "/filename.php", "/folder/filename.php", "/folder/filename.php?parameters", "/folder/any.php.file.html", "/filename.php/"},
+			nomatch: []string{"/", "/windows.PHP"},
+		},
+		{
+			pattern: "/*.php$",
+			match:   []string{"/filename.php", "/folder/filename.php"},
+			nomatch: []string{"/filename.php?parameters", "/filename.php/", "/filename.php5", "/windows.PHP"},
+		},
+		{
+			pattern: "/fish*.php",
+			match:   []string{"/fish.php", "/fishheads/catfish.php?parameters"},
+			nomatch: []string{"/Fish.PHP"},
+		},
+		{
+			pattern: "*.gif$",
+			match:   []string{"/example/disallowed.gif"},
+			nomatch: []string{"/example/disallowed.gif?x"},
+		},
+		{
+			pattern: "/$",
+			match:   []string{"/"},
+			nomatch: []string{"/page"},
+		},
+	} {
+		for _, path := range tc.match {
+			if !robotsMatch(tc.pattern, path) {
+				t.Errorf("%s doesn't match %s", tc.pattern, path)
+			}
+		}
+		for _, path := range tc.nomatch {
+			if robotsMatch(tc.pattern, path) {
+				t.Errorf("%s matches %s", tc.pattern, path)
+			}
+		}
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		allow    []string
+		disallow []string
+		path     string
+		allowed  bool
+	}{
+		{path: "/page", allowed: true},
+		{allow: []string{"/p"}, disallow: []string{"/"}, path: "/page", allowed: true},
+		{allow: []string{"/folder"}, disallow: []string{"/folder"}, path: "/folder/page", allowed: true},
+		{allow: []string{"/page"}, disallow: []string{"/*.htm"}, path: "/page.htm", allowed: false},
+		{allow: []string{"/$"}, disallow: []string{"/"}, path: "/", allowed: true},
+		{allow: []string{"/$"}, disallow: []string{"/"}, path: "/page.htm", allowed: false},
+		{allow: []string{"/example/page/"}, disallow: []string{"/example/page/disallowed.gif"}, path: "/example/page/", allowed: true},
+		{allow: []string{"/example/page/"}, disallow: []string{"/example/page/disallowed.gif"}, path: "/example/page/disallowed.gif", allowed: false},
+		{disallow: []string{"/search?"}, path: "/search?q=x", allowed: false},
+		{disallow: []string{"/"}, path: "", allowed: false},
+	} {
+		path, query, _ := strings.Cut(tc.path, "?")
+		r := robotsRules{allow: tc.allow, disallow: tc.disallow}
+		if got := r.allowed(path, query); got != tc.allowed {
+			t.Errorf("allow %v disallow %v %s: got %v", tc.allow, tc.disallow, tc.path, got)
+		}
+	}
+}
+
+// The RFC 9309 section 5.1 example.
+const rfcRobots = `User-Agent: *
+Disallow: *.gif$
+Disallow: /example/
+Allow: /publications/
+Crawl-delay: 2
+
+User-Agent: foobot
+Disallow:/
+Allow:/example/page.html
+Allow:/example/allowed.gif
+
+User-Agent: barbot
+User-Agent: bazbot
+Disallow: /example/page.html
+crawl-delay: 0.5 # seconds
+
+User-Agent: quxbot
+`
+
+func TestParseRobots(t *testing.T) {
+	for _, tc := range []struct {
+		agent      string
+		allowed    []string
+		disallowed []string
+		delay      time.Duration
+	}{
+		{
+			agent:      "",
+			allowed:    []string{"/", "/publications/", "/example"},
+			disallowed: []string{"/example/page.html", "/a.gif"},
+			delay:      2 * time.Second,
+		},
+		{
+			agent:      "otherbot",
+			allowed:    []string{"/publications/x"},
+			disallowed: []string{"/example/"},
+			delay:      2 * time.Second,
+		},
+		{
+			agent:      "FooBot",
+			allowed:    []string{"/example/page.html", "/example/allowed.gif"},
+			disallowed: []string{"/", "/publications/"},
+		},
+		{
+			agent:      "barbot",
+			allowed:    []string{"/", "/example/", "/a.gif"},
+			disallowed: []string{"/example/page.html"},
+			delay:      500 * time.Millisecond,
+		},
+		{
+			agent:      "bazbot",
+			disallowed: []string{"/example/page.html"},
+			delay:      500 * time.Millisecond,
+		},
+		{
+			agent:   "quxbot",
+			allowed: []string{"/", "/example/page.html", "/a.gif"},
+		},
+	} {
+		t.Run(tc.agent, func(t *testing.T) {
+			r := parseRobots(strings.NewReader(rfcRobots), tc.agent)
+			for _, path := range tc.allowed {
+				if !r.allowed(path, "") {
+					t.Errorf("%s disallowed", path)
+				}
+			}
+			for _, path := range tc.disallowed {
+				if r.allowed(path, "") {
+					t.Errorf("%s allowed", path)
+				}
+			}
+			if r.delay != tc.delay {
+				t.Errorf("delay: got %s, want %s", r.delay, tc.delay)
+			}
+		})
+	}
+}
+
+func TestPolitenessDelay(t *testing.T) {
+	p := NewPoliteness("gomcp", 50*time.Millisecond)
+	// the rules are cached to avoid the network.
+	p.robots["https://example.com"] = &robotsEntry{
+		rules:  robotsRules{disallow: []string{"/search"}},
+		expire: time.Now().Add(time.Hour),
+	}
+
+	ctx := context.Background()
+	if err := p.Wait(ctx, "https://example.com/search?q=x"); !errors.Is(err, ErrRobotsDisallowed) {
+		t.Errorf("disallowed: got %v", err)
+	}
+
+	start := time.Now()
+	for range 3 {
+		if err := p.Delay(ctx, "https://example.com/search?q=x"); err != nil {
+			t.Fatalf("delay: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("requests not spaced: %s", d)
+	}
+
+	// the expired entries are evicted.
+	p.robots["https://example.com"].expire = time.Now().Add(-time.Second)
+	p.mu.Lock()
+	p.swept = time.Time{}
+	p.sweep(time.Now().Add(time.Second))
+	p.mu.Unlock()
+	if len(p.robots) != 0 || len(p.next) != 0 {
+		t.Errorf("entries not evicted: %d robots, %d hosts", len(p.robots), len(p.next))
+	}
+}
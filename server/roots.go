@@ -0,0 +1,136 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+var ErrOriginNotAllowed = errors.New("origin not allowed by the client's roots")
+
+// rootsTimeout bounds the wait of the client's roots.
+const rootsTimeout = 30 * time.Second
+
+// refreshRoots requests the client's roots and keeps their https origins as
+// the origins allowed for the navigation.
+// On error, the navigation is blocked until the next successful refresh.
+func (c *MCPConn) refreshRoots(ctx context.Context) error {
+	if c.ClientCapabilities().Roots == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+	defer cancel()
+
+	var res mcp.RootsListResult
+	err := c.Request(ctx, mcp.RootsListMethod, nil, &res)
+
+	var origins []string
+	for _, r := range res.Roots {
+		u, err := url.Parse(r.URI)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			continue
+		}
+		if o := origin(u); !slices.Contains(origins, o) {
+			origins = append(origins, o)
+		}
+	}
+
+	c.mu.Lock()
+	if err != nil {
+		err = fmt.Errorf("list roots: %w", err)
+		c.origins = nil
+	} else {
+		c.origins = origins
+	}
+	c.rootserr = err
+	c.mu.Unlock()
+
+	// the navigation waits for the first roots.
+	c.rootsonce.Do(func() { close(c.rootsready) })
+
+	return err
+}
+
+// AllowedOrigins returns the origins allowed by the client's https roots.
+// The origins restrict the navigation only if the client sent at least one
+// https root.
+func (c *MCPConn) AllowedOrigins() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.origins)
+}
+
+// checkOrigin returns ErrOriginNotAllowed if the URL's origin isn't allowed
+// by the client's roots.
+// If the client declares the roots capability, it waits for the first roots
+// and blocks all the origins if the roots can't be listed.
+// Without https root, e.g. with file:// roots only, all the origins are
+// allowed.
+func (c *MCPConn) checkOrigin(ctx context.Context, rawurl string) error {
+	if c.ClientCapabilities().Roots == nil {
+		return nil
+	}
+
+	select {
+	case <-c.rootsready:
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s: roots not received: %w", ErrOriginNotAllowed, rawurl, ctx.Err())
+	}
+
+	c.mu.Lock()
+	origins, rootserr := slices.Clone(c.origins), c.rootserr
+	c.mu.Unlock()
+
+	if rootserr != nil {
+		return fmt.Errorf("%w: %s: %w", ErrOriginNotAllowed, rawurl, rootserr)
+	}
+
+	if len(origins) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+
+	if !slices.Contains(origins, origin(u)) {
+		return fmt.Errorf("%w: %s, allowed: %s", ErrOriginNotAllowed, rawurl, strings.Join(origins, ", "))
+	}
+
+	return nil
+}
+
+// origin returns the scheme://host[:port] of the URL, w/o the default port.
+func origin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if port == "" || (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + host + ":" + port
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/lightpanda-io/gomcp/mcp"
+	"github.com/lightpanda-io/gomcp/rpc"
+)
+
+// rootsConn returns a connection whose client answers roots/list with the
+// roots, or with the error if not nil.
+func rootsConn(t *testing.T, roots []mcp.Root, rerr *rpc.Error) *MCPConn {
+	t.Helper()
+
+	srv := New(context.Background(), WithNetPolicy(nil))
+
+	var c *MCPConn
+	c = srv.NewConn(func(_ string, data any) error {
+		call, ok := data.(rpc.Call)
+		if !ok || call.Method != mcp.RootsListMethod {
+			return nil
+		}
+		res := rpc.Request{Id: call.Id, Error: rerr}
+		if rerr == nil {
+			b, err := json.Marshal(mcp.RootsListResult{Roots: roots})
+			if err != nil {
+				return err
+			}
+			res.Result = b
+		}
+		go c.resolve(res)
+		return nil
+	})
+	c.initialize(mcp.Info{}, mcp.ClientCapabilities{Roots: &mcp.ListChangedCapability{}})
+
+	return c
+}
+
+func TestCheckOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		roots   []mcp.Root
+		rerr    *rpc.Error
+		origins []string
+		allowed map[string]bool
+	}{
+		{
+			name: "no roots",
+			allowed: map[string]bool{
+				"https://example.com/": true,
+				"http://other.org/a":   true,
+			},
+		},
+		{
+			name:  "file roots",
+			roots: []mcp.Root{{URI: "file:///home/user/project"}},
+			allowed: map[string]bool{
+				"https://example.com/": true,
+			},
+		},
+		{
+			name: "https roots",
+			roots: []mcp.Root{
+				{URI: "file:///home/user/project"},
+				{URI: "https://Example.com:443/docs"},
+				{URI: "https://example.com/api"},
+				{URI: "https://api.example.com:8443/"},
+			},
+			origins: []string{"https://example.com", "https://api.example.com:8443"},
+			allowed: map[string]bool{
+				"https://example.com/other":      true,
+				"https://api.example.com:8443/x": true,
+				"https://api.example.com/x":      false,
+				"http://example.com/":            false,
+				"https://other.org/":             false,
+			},
+		},
+		{
+			name: "roots error",
+			rerr: &rpc.Error{Code: rpc.InternalError, Message: "boom"},
+			allowed: map[string]bool{
+				"https://example.com/": false,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := rootsConn(t, tc.roots, tc.rerr)
+
+			err := c.refreshRoots(context.Background())
+			if (err != nil) != (tc.rerr != nil) {
+				t.Fatalf("refresh roots: %v", err)
+			}
+			if got := c.AllowedOrigins(); !slices.Equal(got, tc.origins) {
+				t.Errorf("origins: got %v, want %v", got, tc.origins)
+			}
+
+			for u, allowed := range tc.allowed {
+				err := c.checkOrigin(context.Background(), u)
+				if allowed && err != nil {
+					t.Errorf("%s: got %v", u, err)
+				}
+				if !allowed && !errors.Is(err, ErrOriginNotAllowed) {
+					t.Errorf("%s: got %v, want %v", u, err, ErrOriginNotAllowed)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOriginWait(t *testing.T) {
+	c := rootsConn(t, nil, nil)
+
+	// the navigation waits for the first roots.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.checkOrigin(ctx, "https://example.com/"); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Errorf("got %v, want %v", err, ErrOriginNotAllowed)
+	}
+
+	// w/o the roots capability, the navigation isn't restricted.
+	c.initialize(mcp.Info{}, mcp.ClientCapabilities{})
+	if err := c.checkOrigin(ctx, "https://example.com/"); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCheckRequestRedirect(t *testing.T) {
+	c := rootsConn(t, []mcp.Root{{URI: "https://example.com/"}}, nil)
+	if err := c.refreshRoots(context.Background()); err != nil {
+		t.Fatalf("refresh roots: %v", err)
+	}
+
+	paused := func(u string, typ network.ResourceType) *fetch.EventRequestPaused {
+		return &fetch.EventRequestPaused{
+			Request:      &network.Request{URL: u},
+			ResourceType: typ,
+		}
+	}
+
+	// a document redirected to another origin is blocked.
+	if err := c.checkRequest(context.Background(), paused("https://example.com/login", network.ResourceTypeDocument)); err != nil {
+		t.Errorf("same origin: got %v", err)
+	}
+	if err := c.checkRequest(context.Background(), paused("https://evil.org/", network.ResourceTypeDocument)); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Errorf("redirect: got %v, want %v", err, ErrOriginNotAllowed)
+	}
+
+	// the sub-resources aren't restricted by the roots.
+	if err := c.checkRequest(context.Background(), paused("https://cdn.org/app.js", network.ResourceTypeScript)); err != nil {
+		t.Errorf("script: got %v", err)
+	}
+}
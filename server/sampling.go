@@ -0,0 +1,79 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+var ErrNoSampling = errors.New("the client doesn't support sampling")
+
+const (
+	// samplingTimeout bounds the wait of the client's LLM response, which
+	// can require the user's approval.
+	samplingTimeout = 2 * time.Minute
+	// summaryMaxTokens is the maximum length of the summaries.
+	summaryMaxTokens = 1024
+)
+
+// CreateMessage asks the client's LLM to generate a message.
+// It returns ErrNoSampling if the client doesn't support sampling.
+func (c *MCPConn) CreateMessage(ctx context.Context, params mcp.SamplingCreateMessageParams) (mcp.SamplingCreateMessageResult, error) {
+	var res mcp.SamplingCreateMessageResult
+
+	if c.ClientCapabilities().Sampling == nil {
+		return res, ErrNoSampling
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, samplingTimeout)
+	defer cancel()
+
+	if err := c.Request(ctx, mcp.SamplingCreateMessageMethod, params, &res); err != nil {
+		return res, fmt.Errorf("create message: %w", err)
+	}
+
+	return res, nil
+}
+
+// Summarize asks the client's LLM to condense the page content following the
+// instruction, a default instruction is used if empty.
+func (c *MCPConn) Summarize(ctx context.Context, content, instruction string) (string, error) {
+	if instruction == "" {
+		instruction = "Summarize the following web page content, keeping the key facts, figures and links."
+	}
+
+	res, err := c.CreateMessage(ctx, mcp.SamplingCreateMessageParams{
+		SystemPrompt: "You condense web pages for an AI agent browsing the web.",
+		Messages: []mcp.SamplingMessage{{
+			Role:    mcp.RoleUser,
+			Content: mcp.NewTextContent(instruction + "\n\n" + content),
+		}},
+		MaxTokens: summaryMaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if res.Content.Type != "text" {
+		return "", fmt.Errorf("summarize: unexpected %s content", res.Content.Type)
+	}
+
+	return res.Content.Text, nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// A search query.
+type SearchQuery struct {
+	// Text is the searched terms.
+	Text string `json:"text"`
+	// Site restricts the results to a domain.
+	Site string `json:"site,omitempty"`
+	// Page is the results page number, starting at 1.
+	Page int `json:"page,omitempty"`
+	// Region is a language-country code, like en-US.
+	Region string `json:"region,omitempty"`
+}
+
+// terms returns the query text including the site filter.
+func (q SearchQuery) terms() string {
+	if q.Site == "" {
+		return q.Text
+	}
+	return "site:" + q.Site + " " + q.Text
+}
+
+// page returns the 0 based page index.
+func (q SearchQuery) page() int {
+	return max(q.Page-1, 0)
+}
+
+// region returns the lowercased language and country of the region.
+// A region with a single part is used for both.
+func (q SearchQuery) region() (lang, country string) {
+	if q.Region == "" {
+		return "", ""
+	}
+
+	lang, country, ok := strings.Cut(strings.ToLower(q.Region), "-")
+	if !ok {
+		return lang, lang
+	}
+	return lang, country
+}
+
+// A search result extracted from a search engine page.
+type SearchResult struct {
+	// Rank is the position of the result in the results page, starting at 1
+	// on each page: the engines' page sizes differ from the limit.
+	Rank    int    `json:"rank"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// SearchProvider describes a search engine.
+// The browser loads the page returned by URL and the provider extracts the
+// results from the loaded document.
+type SearchProvider interface {
+	// URL returns the search page URL for the query.
+	URL(query SearchQuery) string
+	// Results extracts the search results from the page's HTML.
+	Results(html string) ([]SearchResult, error)
+}
+
+const (
+	SearchDuckDuckGo = "duckduckgo"
+	SearchBing       = "bing"
+	SearchBrave      = "brave"
+	SearchSearXNG    = "searxng"
+	SearchTemplate   = "template"
+
+	SearchDefault = SearchDuckDuckGo
+)
+
+var ErrSearchProvider = errors.New("invalid search provider")
+
+// NewSearchProvider returns the provider corresponding to the name.
+// rawurl is required by searxng, the instance base URL, and template, the
+// search URL containing a {query} placeholder. It's ignored by the others.
+func NewSearchProvider(name, rawurl string) (SearchProvider, error) {
+	switch name {
+	case SearchDuckDuckGo:
+		return DuckDuckGo{}, nil
+	case SearchBing:
+		return Bing{}, nil
+	case SearchBrave:
+		return Brave{}, nil
+	case SearchSearXNG:
+		if rawurl == "" {
+			return nil, fmt.Errorf("%w: searxng requires an instance url", ErrSearchProvider)
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("%w: searxng url: %w", ErrSearchProvider, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: searxng url must be an absolute http(s) url: %s", ErrSearchProvider, rawurl)
+		}
+		return SearXNG{BaseURL: strings.TrimRight(rawurl, "/")}, nil
+	case SearchTemplate:
+		if !strings.Contains(rawurl, "{query}") {
+			return nil, fmt.Errorf("%w: template url must contain {query}", ErrSearchProvider)
+		}
+		return Template{URLTemplate: rawurl}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSearchProvider, name)
+}
+
+// DuckDuckGo uses the javascript free HTML version of DuckDuckGo.
+type DuckDuckGo struct{}
+
+func (DuckDuckGo) URL(query SearchQuery) string {
+	v := url.Values{"q": {query.terms()}}
+	if p := query.page(); p > 0 {
+		v.Set("s", strconv.Itoa(p*ddgPageSize))
+		v.Set("dc", strconv.Itoa(p*ddgPageSize+1))
+	}
+	if lang, country := query.region(); lang != "" {
+		v.Set("kl", country+"-"+lang)
+	}
+
+	return "https://html.duckduckgo.com/html/?" + v.Encode()
+}
+
+// ddgPageSize is the number of results per page on DuckDuckGo HTML.
+const ddgPageSize = 30
+
+func (DuckDuckGo) Results(html string) ([]SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var res []SearchResult
+	doc.Find(".result").Not(".result--ad").Each(func(_ int, s *goquery.Selection) {
+		a := s.Find("a.result__a").First()
+		href, ok := a.Attr("href")
+		if !ok {
+			return
+		}
+
+		res = appendResult(res, SearchResult{
+			Title:   a.Text(),
+			URL:     ddgURL(href),
+			Snippet: s.Find(".result__snippet").First().Text(),
+		})
+	})
+
+	return res, nil
+}
+
+// ddgURL extracts the target URL from a DuckDuckGo redirect link.
+func ddgURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+
+	if v := u.Query().Get("uddg"); v != "" {
+		return v
+	}
+
+	return href
+}
+
+// Bing uses the Bing web search.
+type Bing struct{}
+
+func (Bing) URL(query SearchQuery) string {
+	v := url.Values{"q": {query.terms()}}
+	if p := query.page(); p > 0 {
+		v.Set("first", strconv.Itoa(p*10+1))
+	}
+	if lang, country := query.region(); lang != "" {
+		v.Set("mkt", lang+"-"+strings.ToUpper(country))
+	}
+
+	return "https://www.bing.com/search?" + v.Encode()
+}
+
+func (Bing) Results(html string) ([]SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var res []SearchResult
+	doc.Find("li.b_algo").Each(func(_ int, s *goquery.Selection) {
+		a := s.Find("h2 a").First()
+		href, ok := a.Attr("href")
+		if !ok {
+			return
+		}
+
+		res = appendResult(res, SearchResult{
+			Title:   a.Text(),
+			URL:     bingURL(href),
+			Snippet: s.Find(".b_caption p, p").First().Text(),
+		})
+	})
+
+	return res, nil
+}
+
+// bingURL extracts the target URL from a Bing click tracking link.
+// The target is base64 encoded in the u parameter with an a1 prefix.
+func bingURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || u.Host != "www.bing.com" || u.Path != "/ck/a" {
+		return href
+	}
+
+	v, ok := strings.CutPrefix(u.Query().Get("u"), "a1")
+	if !ok {
+		return href
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return href
+	}
+
+	return string(b)
+}
+
+// Brave uses the Brave web search.
+type Brave struct{}
+
+func (Brave) URL(query SearchQuery) string {
+	v := url.Values{"q": {query.terms()}}
+	if p := query.page(); p > 0 {
+		v.Set("offset", strconv.Itoa(p))
+	}
+	if _, country := query.region(); country != "" {
+		v.Set("country", country)
+	}
+
+	return "https://search.brave.com/search?" + v.Encode()
+}
+
+func (Brave) Results(html string) ([]SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var res []SearchResult
+	doc.Find(`#results .snippet[data-type="web"]`).Each(func(_ int, s *goquery.Selection) {
+		a := s.Find("a[href]").First()
+		href, ok := a.Attr("href")
+		if !ok {
+			return
+		}
+
+		res = appendResult(res, SearchResult{
+			Title:   s.Find(".title, .snippet-title").First().Text(),
+			URL:     href,
+			Snippet: s.Find(".snippet-description, .generic-snippet .content").First().Text(),
+		})
+	})
+
+	return res, nil
+}
+
+// SearXNG uses a SearXNG instance, usually self-hosted.
+type SearXNG struct {
+	BaseURL string
+}
+
+func (p SearXNG) URL(query SearchQuery) string {
+	v := url.Values{"q": {query.terms()}}
+	if page := query.page(); page > 0 {
+		v.Set("pageno", strconv.Itoa(page+1))
+	}
+	if lang, country := query.region(); lang != "" {
+		v.Set("language", lang+"-"+strings.ToUpper(country))
+	}
+
+	return p.BaseURL + "/search?" + v.Encode()
+}
+
+func (SearXNG) Results(html string) ([]SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var res []SearchResult
+	doc.Find("article.result").Each(func(_ int, s *goquery.Selection) {
+		a := s.Find("h3 a").First()
+		href, ok := a.Attr("href")
+		if !ok {
+			return
+		}
+
+		res = appendResult(res, SearchResult{
+			Title:   a.Text(),
+			URL:     href,
+			Snippet: s.Find("p.content").First().Text(),
+		})
+	})
+
+	return res, nil
+}
+
+// Template uses any search engine from an URL template.
+// The {query} placeholder is replaced by the escaped query. The optional
+// {page} and {region} placeholders are replaced by the page number and the
+// region.
+// Since the page structure is unknown, the results are the external links
+// of the page.
+type Template struct {
+	URLTemplate string
+}
+
+func (p Template) URL(query SearchQuery) string {
+	return strings.NewReplacer(
+		"{query}", url.QueryEscape(query.terms()),
+		"{page}", strconv.Itoa(query.page()+1),
+		"{region}", url.QueryEscape(query.Region),
+	).Replace(p.URLTemplate)
+}
+
+func (p Template) Results(html string) ([]SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	// ignore the links to the search engine itself.
+	var host string
+	if u, err := url.Parse(p.URLTemplate); err == nil {
+		host = u.Hostname()
+	}
+
+	seen := make(map[string]struct{})
+
+	var res []SearchResult
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		u, err := url.Parse(href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		if u.Hostname() == host {
+			return
+		}
+		if _, ok := seen[href]; ok {
+			return
+		}
+		seen[href] = struct{}{}
+
+		res = appendResult(res, SearchResult{
+			Title: a.Text(),
+			URL:   href,
+		})
+	})
+
+	return res, nil
+}
+
+// appendResult cleans up the result and appends it if it's usable.
+func appendResult(res []SearchResult, r SearchResult) []SearchResult {
+	r.Title = normalizeSpace(r.Title)
+	r.URL = strings.TrimSpace(r.URL)
+	r.Snippet = normalizeSpace(r.Snippet)
+
+	if r.Title == "" || r.URL == "" {
+		return res
+	}
+
+	return append(res, r)
+}
+
+// normalizeSpace trims and collapses the whitespaces.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// rankSearchResults keeps the limit first results and sets their rank in
+// the page.
+// The results are never nil, the structured content requires an array.
+func rankSearchResults(res []SearchResult, limit int) []SearchResult {
+	if res == nil {
+		return []SearchResult{}
+	}
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	for i := range res {
+		res[i].Rank = i + 1
+	}
+
+	return res
+}
+
+// formatSearchResults returns a readable text rendering of the results.
+func formatSearchResults(res []SearchResult) string {
+	if len(res) == 0 {
+		return "No result found."
+	}
+
+	var b strings.Builder
+	for _, r := range res {
+		fmt.Fprintf(&b, "%d. %s\n   %s\n", r.Rank, r.Title, r.URL)
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", r.Snippet)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSearchResults(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		provider SearchProvider
+		html     string
+		want     []SearchResult
+	}{
+		{
+			name:     "duckduckgo",
+			provider: DuckDuckGo{},
+			html: `<div class="result">
+	<a class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2Fa&amp;rut=x">Example
+	A</a>
+	<a class="result__snippet">The  first result.</a>
+</div>
+<div class="result result--ad">
+	<a class="result__a" href="https://ads.example.com/">Ad</a>
+</div>
+<div class="result">
+	<a class="result__a" href="https://example.com/b">Example B</a>
+</div>
+<div class="result"><a class="result__a">No link</a></div>`,
+			want: []SearchResult{
+				{Title: "Example A", URL: "https://example.com/a", Snippet: "The first result."},
+				{Title: "Example B", URL: "https://example.com/b"},
+			},
+		},
+		{
+			name:     "bing",
+			provider: Bing{},
+			html: `<ol id="b_results">
+<li class="b_algo">
+	<h2><a href="https://www.bing.com/ck/a?!&amp;&amp;p=x&amp;u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n&amp;ntb=1">Bing result</a></h2>
+	<div class="b_caption"><p>A  snippet.</p></div>
+</li>
+<li class="b_algo">
+	<h2><a href="https://example.com/direct">Direct</a></h2>
+	<p>Other snippet.</p>
+</li>
+<li class="b_ad"><h2><a href="https://ads.example.com/">Ad</a></h2></li>
+</ol>`,
+			want: []SearchResult{
+				{Title: "Bing result", URL: "https://example.com/bing", Snippet: "A snippet."},
+				{Title: "Direct", URL: "https://example.com/direct", Snippet: "Other snippet."},
+			},
+		},
+		{
+			name:     "brave",
+			provider: Brave{},
+			html: `<div id="results">
+<div class="snippet" data-type="web">
+	<a href="https://example.com/brave"><div class="title">Brave result</div></a>
+	<div class="snippet-description">A snippet.</div>
+</div>
+<div class="snippet" data-type="news">
+	<a href="https://news.example.com/"><div class="title">News</div></a>
+</div>
+<div class="snippet" data-type="web">
+	<a href="https://example.com/generic"><div class="snippet-title">Generic</div></a>
+	<div class="generic-snippet"><div class="content">Generic snippet.</div></div>
+</div>
+</div>`,
+			want: []SearchResult{
+				{Title: "Brave result", URL: "https://example.com/brave", Snippet: "A snippet."},
+				{Title: "Generic", URL: "https://example.com/generic", Snippet: "Generic snippet."},
+			},
+		},
+		{
+			name:     "searxng",
+			provider: SearXNG{BaseURL: "https://searx.example.com"},
+			html: `<div id="urls">
+<article class="result">
+	<h3><a href="https://example.com/searx">SearXNG result</a></h3>
+	<p class="content">A snippet.</p>
+</article>
+<article class="result"><h3><a href="https://example.com/empty"> </a></h3></article>
+</div>`,
+			want: []SearchResult{
+				{Title: "SearXNG result", URL: "https://example.com/searx", Snippet: "A snippet."},
+			},
+		},
+		{
+			name:     "template",
+			provider: Template{URLTemplate: "https://search.example.com/?q={query}"},
+			html: `<a href="https://search.example.com/next">Next</a>
+<a href="https://example.com/one">One</a>
+<a href="/relative">Relative</a>
+<a href="javascript:void(0)">Script</a>
+<a href="https://example.com/one">One again</a>
+<a href="http://example.org/two">Two</a>`,
+			want: []SearchResult{
+				{Title: "One", URL: "https://example.com/one"},
+				{Title: "Two", URL: "http://example.org/two"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.provider.Results(tc.html)
+			if err != nil {
+				t.Fatalf("results: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSearchURL(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		provider SearchProvider
+		query    SearchQuery
+		want     string
+	}{
+		{"duckduckgo", DuckDuckGo{}, SearchQuery{Text: "go mcp"}, "https://html.duckduckgo.com/html/?q=go+mcp"},
+		{"duckduckgo page", DuckDuckGo{}, SearchQuery{Text: "go", Page: 3}, "https://html.duckduckgo.com/html/?dc=61&q=go&s=60"},
+		{"duckduckgo region", DuckDuckGo{}, SearchQuery{Text: "go", Region: "fr-BE"}, "https://html.duckduckgo.com/html/?kl=be-fr&q=go"},
+		{"duckduckgo site", DuckDuckGo{}, SearchQuery{Text: "go", Site: "go.dev"}, "https://html.duckduckgo.com/html/?q=site%3Ago.dev+go"},
+		{"bing", Bing{}, SearchQuery{Text: "go"}, "https://www.bing.com/search?q=go"},
+		{"bing page", Bing{}, SearchQuery{Text: "go", Page: 2}, "https://www.bing.com/search?first=11&q=go"},
+		{"bing region", Bing{}, SearchQuery{Text: "go", Region: "en-us"}, "https://www.bing.com/search?mkt=en-US&q=go"},
+		{"bing single region", Bing{}, SearchQuery{Text: "go", Region: "fr"}, "https://www.bing.com/search?mkt=fr-FR&q=go"},
+		{"brave", Brave{}, SearchQuery{Text: "go"}, "https://search.brave.com/search?q=go"},
+		{"brave page", Brave{}, SearchQuery{Text: "go", Page: 2}, "https://search.brave.com/search?offset=1&q=go"},
+		{"brave region", Brave{}, SearchQuery{Text: "go", Region: "en-GB"}, "https://search.brave.com/search?country=gb&q=go"},
+		{"searxng", SearXNG{BaseURL: "https://searx.example.com"}, SearchQuery{Text: "go"}, "https://searx.example.com/search?q=go"},
+		{"searxng page", SearXNG{BaseURL: "https://searx.example.com"}, SearchQuery{Text: "go", Page: 2}, "https://searx.example.com/search?pageno=2&q=go"},
+		{"searxng region", SearXNG{BaseURL: "https://searx.example.com"}, SearchQuery{Text: "go", Region: "de-at"}, "https://searx.example.com/search?language=de-AT&q=go"},
+		{
+			"template",
+			Template{URLTemplate: "https://example.com/s?q={query}&p={page}&r={region}"},
+			SearchQuery{Text: "go & mcp", Page: 2, Region: "en-US"},
+			"https://example.com/s?q=go+%26+mcp&p=2&r=en-US",
+		},
+		{
+			"template first page",
+			Template{URLTemplate: "https://example.com/s?q={query}&p={page}"},
+			SearchQuery{Text: "go"},
+			"https://example.com/s?q=go&p=1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.provider.URL(tc.query); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedirectURL(t *testing.T) {
+	for _, tc := range []struct {
+		href string
+		fn   func(string) string
+		want string
+	}{
+		{"//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2F%3Fa%3D1&rut=x", ddgURL, "https://example.com/?a=1"},
+		{"https://example.com/", ddgURL, "https://example.com/"},
+		{"https://www.bing.com/ck/a?u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n", bingURL, "https://example.com/bing"},
+		{"https://www.bing.com/ck/a?u=b1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n", bingURL, "https://www.bing.com/ck/a?u=b1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n"},
+		{"https://www.bing.com/ck/a?u=a1%%%", bingURL, "https://www.bing.com/ck/a?u=a1%%%"},
+		{"https://evil.com/ck/a?u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n", bingURL, "https://evil.com/ck/a?u=a1aHR0cHM6Ly9leGFtcGxlLmNvbS9iaW5n"},
+	} {
+		if got := tc.fn(tc.href); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.href, got, tc.want)
+		}
+	}
+}
+
+func TestNewSearchProvider(t *testing.T) {
+	for _, tc := range []struct {
+		name, url string
+		want      SearchProvider
+	}{
+		{SearchDuckDuckGo, "", DuckDuckGo{}},
+		{SearchBing, "ignored", Bing{}},
+		{SearchBrave, "", Brave{}},
+		{SearchSearXNG, "https://searx.example.com/", SearXNG{BaseURL: "https://searx.example.com"}},
+		{SearchSearXNG, "http://10.0.0.5:8080", SearXNG{BaseURL: "http://10.0.0.5:8080"}},
+		{SearchTemplate, "https://example.com/?q={query}", Template{URLTemplate: "https://example.com/?q={query}"}},
+	} {
+		got, err := NewSearchProvider(tc.name, tc.url)
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.name, tc.url, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: got %#v, want %#v", tc.name, tc.url, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ name, url string }{
+		{"google", ""},
+		{SearchSearXNG, ""},
+		{SearchSearXNG, "searx.example.com"},
+		{SearchSearXNG, "/search"},
+		{SearchSearXNG, "ftp://searx.example.com"},
+		{SearchSearXNG, "https://"},
+		{SearchSearXNG, "://bad"},
+		{SearchTemplate, "https://example.com/?q="},
+	} {
+		if _, err := NewSearchProvider(tc.name, tc.url); !errors.Is(err, ErrSearchProvider) {
+			t.Errorf("%s %q: got %v, want %v", tc.name, tc.url, err, ErrSearchProvider)
+		}
+	}
+}
+
+func TestRankSearchResults(t *testing.T) {
+	// no result is an empty array.
+	b, err := json.Marshal(rankSearchResults(nil, 10))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(b) != "[]" {
+		t.Errorf("no result: got %s", b)
+	}
+
+	res := rankSearchResults([]SearchResult{{URL: "a"}, {URL: "b"}, {URL: "c"}}, 2)
+	if len(res) != 2 {
+		t.Fatalf("limit: got %d results", len(res))
+	}
+	for i, r := range res {
+		if r.Rank != i+1 {
+			t.Errorf("%s: got rank %d, want %d", r.URL, r.Rank, i+1)
+		}
+	}
+}
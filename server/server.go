@@ -0,0 +1,598 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server implements the Lightpanda MCP server and its stdio and
+// HTTP SSE transports.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+	"github.com/lightpanda-io/gomcp/rpc"
+)
+
+type MCPServer struct {
+	Name    string
+	Version string
+
+	cdpctx         context.Context
+	search         SearchProvider
+	keepalive      time.Duration
+	stdiokeepalive time.Duration
+	grace          time.Duration
+	pagesize       int
+	netpolicy      *NetPolicy
+	politeness     *Politeness
+	authn          Authenticators
+	oauth          *OAuth
+	origins        []string
+
+	sessionLimits   Limits
+	principalLimits Limits
+
+	mu      sync.Mutex
+	tools   []ToolHandler
+	prompts []PromptTemplate
+	conns   map[*MCPConn]struct{}
+	// limiters are the principals' limiters by name.
+	limiters     map[string]*limiter
+	limiterswept time.Time
+}
+
+// An Option configures the server.
+type Option func(*MCPServer)
+
+// WithInfo sets the server's name and version sent to the clients.
+func WithInfo(name, version string) Option {
+	return func(s *MCPServer) {
+		s.Name = name
+		s.Version = version
+	}
+}
+
+// WithKeepAlive sets the interval between the pings sent to the HTTP
+// clients.
+// The connections of the clients not answering in time are closed.
+// Zero disables the pings.
+func WithKeepAlive(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.keepalive = d
+	}
+}
+
+// WithStdioKeepAlive sets the interval between the pings sent to the stdio
+// client, the server exits if the client doesn't answer in time.
+// The pings are disabled by default: the stdio client runs the server and
+// may not answer while busy.
+func WithStdioKeepAlive(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.stdiokeepalive = d
+	}
+}
+
+// WithSessionGrace sets the duration a SSE session outlives its stream, the
+// client reconnecting in time resumes it and gets the missed messages.
+// Zero closes the sessions on disconnection.
+func WithSessionGrace(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.grace = d
+	}
+}
+
+// WithPageSize sets the maximum number of items returned by the list
+// requests, the clients get the next items with the returned cursor.
+// Zero disables the pagination.
+func WithPageSize(n int) Option {
+	return func(s *MCPServer) {
+		s.pagesize = n
+	}
+}
+
+// WithNetPolicy sets the policy restricting the URLs loaded by the browser.
+// DefaultNetPolicy is used by default, nil disables the restrictions.
+func WithNetPolicy(p *NetPolicy) Option {
+	return func(s *MCPServer) {
+		s.netpolicy = p
+	}
+}
+
+// WithPoliteness makes the navigations respect the sites' robots.txt and
+// space the requests to the same host, across all the sessions.
+// The navigations are not restricted by default.
+func WithPoliteness(p *Politeness) Option {
+	return func(s *MCPServer) {
+		s.politeness = p
+	}
+}
+
+// WithAuthenticator adds an authentication method to the HTTP transport, the
+// requests are not authenticated by default.
+func WithAuthenticator(a Authenticator) Option {
+	return func(s *MCPServer) {
+		s.authn = append(s.authn, a)
+	}
+}
+
+// WithOAuth authenticates the HTTP transport with OAuth access tokens,
+// serves the protected resource metadata and restricts the tools by scope.
+func WithOAuth(o *OAuth) Option {
+	return func(s *MCPServer) {
+		s.oauth = o
+		s.authn = append(s.authn, o)
+	}
+}
+
+// WithCORSOrigins sets the origins allowed to make cross origin requests
+// with credentials to the HTTP transport.
+// All the origins are allowed w/o credentials by default.
+func WithCORSOrigins(origins ...string) Option {
+	return func(s *MCPServer) {
+		s.origins = origins
+	}
+}
+
+// WithLimits sets the usage limits of each session and of each
+// authenticated principal across its sessions.
+// The usage is unlimited by default.
+func WithLimits(session, principal Limits) Option {
+	return func(s *MCPServer) {
+		s.sessionLimits = session
+		s.principalLimits = principal
+	}
+}
+
+// WithSearchProvider sets the search engine used by the search tool.
+// DuckDuckGo is used by default.
+func WithSearchProvider(p SearchProvider) Option {
+	return func(s *MCPServer) {
+		s.search = p
+	}
+}
+
+const (
+	DefaultName     = "lightpanda go mcp"
+	DefaultVersion  = "1.0.0"
+	DefaultPageSize = 100
+)
+
+// New returns a server with the builtin tools and prompts registered.
+// cdpctx is the chromedp allocator context used to create the browser tabs,
+// usually created with chromedp.NewRemoteAllocator.
+func New(cdpctx context.Context, opts ...Option) *MCPServer {
+	s := &MCPServer{
+		Name:      DefaultName,
+		Version:   DefaultVersion,
+		cdpctx:    cdpctx,
+		search:    DuckDuckGo{},
+		keepalive: DefaultKeepAlive,
+		grace:     DefaultSessionGrace,
+		pagesize:  DefaultPageSize,
+		netpolicy: DefaultNetPolicy(),
+		conns:     make(map[*MCPConn]struct{}),
+		limiters:  make(map[string]*limiter),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// the robots.txt redirects follow the browser's restrictions.
+	if s.politeness != nil {
+		s.politeness.policy = s.netpolicy
+	}
+
+	s.tools = builtinTools(s.search)
+	s.prompts = builtinPrompts()
+
+	return s
+}
+
+// NewConn returns a connection sending its messages with send.
+func (s *MCPServer) NewConn(send SendFn) *MCPConn {
+	return s.newConn(send, Principal{})
+}
+
+// newConn returns a connection of the authenticated principal.
+func (s *MCPServer) newConn(send SendFn, principal Principal) *MCPConn {
+	c := &MCPConn{
+		srv:           s,
+		principal:     principal,
+		limiter:       newLimiter(s.sessionLimits),
+		send:          send,
+		pending:       make(map[int]chan rpc.Request),
+		subscriptions: make(map[string]struct{}),
+		rootsready:    make(chan struct{}),
+	}
+
+	c.loglevel.Set(DefaultLogLevel)
+	c.logger = slog.New(teeHandler{slog.Default().Handler(), &connHandler{conn: c}})
+	c.toolnames = toolNames(c.ListTools())
+
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	return c
+}
+
+// removeConn forgets the closed connection.
+func (s *MCPServer) removeConn(c *MCPConn) {
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+}
+
+// Register adds a tool to the server.
+// It returns ErrToolExists if a tool with the same name is already
+// registered.
+func (s *MCPServer) Register(h ToolHandler) error {
+	name := h.Tool().Name
+	if name == "" {
+		return errors.New("tool without name")
+	}
+
+	s.mu.Lock()
+	if slices.ContainsFunc(s.tools, func(t ToolHandler) bool { return t.Tool().Name == name }) {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrToolExists, name)
+	}
+
+	s.tools = append(s.tools, h)
+	s.mu.Unlock()
+
+	s.toolsChanged()
+	return nil
+}
+
+// Unregister removes the tool from the server.
+// It returns false if no tool corresponds to the name.
+func (s *MCPServer) Unregister(name string) bool {
+	s.mu.Lock()
+	n := len(s.tools)
+	s.tools = slices.DeleteFunc(s.tools, func(t ToolHandler) bool { return t.Tool().Name == name })
+	removed := len(s.tools) != n
+	s.mu.Unlock()
+
+	if removed {
+		s.toolsChanged()
+	}
+	return removed
+}
+
+// toolsChanged notifies the connections whose tool list changed.
+func (s *MCPServer) toolsChanged() {
+	s.mu.Lock()
+	conns := make([]*MCPConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.toolsChanged()
+	}
+}
+
+// handlers returns a copy of the registered tools.
+func (s *MCPServer) handlers() []ToolHandler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.tools)
+}
+
+// tool returns the registered tool corresponding to the name.
+func (s *MCPServer) tool(name string) (ToolHandler, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.tools, func(t ToolHandler) bool { return t.Tool().Name == name })
+	if i < 0 {
+		return nil, false
+	}
+
+	return s.tools[i], true
+}
+
+// ListTools returns the registered tools definitions in registration order,
+// including the tools unavailable for some connections.
+func (s *MCPServer) ListTools() []mcp.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tools := make([]mcp.Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, t.Tool())
+	}
+
+	return tools
+}
+
+var (
+	ErrNoTool     = errors.New("no tool found")
+	ErrToolExists = errors.New("tool already registered")
+)
+
+func (s *MCPServer) CallTool(ctx context.Context, conn *MCPConn, req mcp.ToolsCallRequest) (mcp.ToolsCallResponse, error) {
+	h, ok := s.tool(req.Params.Name)
+	if !ok || !available(h, conn) {
+		return mcp.ToolsCallResponse{}, ErrNoTool
+	}
+
+	args := req.Params.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	// validate the arguments before dispatch.
+	if err := h.Tool().InputSchema.Validate(args); err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+
+	return h.Call(ctx, conn, args)
+}
+
+// Capabilities returns the capabilities advertised to the clients.
+func (s *MCPServer) Capabilities() mcp.ServerCapabilities {
+	return mcp.ServerCapabilities{
+		Tools:       &mcp.ListChangedCapability{ListChanged: true},
+		Resources:   &mcp.ResourcesCapability{Subscribe: true, ListChanged: true},
+		Prompts:     &mcp.ListChangedCapability{},
+		Logging:     &mcp.EmptyCapability{},
+		Completions: &mcp.EmptyCapability{},
+	}
+}
+
+// resultSize returns the size of the tool result's content.
+func resultSize(res mcp.ToolsCallResponse) int64 {
+	var n int64
+	for _, c := range res.Content {
+		n += int64(len(c.Text) + len(c.Data))
+	}
+	return n
+}
+
+var ErrRPCRequest = errors.New("rpc request error")
+
+// Decode a message
+func (s *MCPServer) Decode(in io.Reader) (mcp.Request, error) {
+	var empty mcp.Request
+
+	dec := json.NewDecoder(in)
+	var rreq rpc.Request
+	if err := dec.Decode(&rreq); err != nil {
+		return empty, fmt.Errorf("json decode: %w", err)
+	}
+
+	if err := rreq.Validate(); err != nil {
+		return empty, fmt.Errorf("rpc validate: %w", err)
+	}
+
+	// The rpc request contains an error not related to a server request.
+	if err := rreq.Err(); err != nil && !rreq.IsResponse() {
+		return empty, errors.Join(ErrRPCRequest, err)
+	}
+
+	mcpreq, err := mcp.Decode(rreq)
+	if err != nil {
+		return empty, fmt.Errorf("mcp validate: %w", err)
+	}
+
+	return mcpreq, err
+}
+
+type SendFn func(string, any) error
+
+func (s *MCPServer) Handle(
+	ctx context.Context,
+	rreq mcp.Request,
+	mcpconn *MCPConn,
+	send SendFn,
+) error {
+	var senderr error
+	switch r := rreq.(type) {
+	case mcp.InitializeRequest:
+		mcpconn.initialize(r.Params.ClientInfo, r.Params.Capabilities)
+		senderr = send("message", rpc.NewResponse(mcp.InitializeResponse{
+			ProtocolVersion: mcp.NegotiateVersion(r.Params.ProtocolVersion),
+			ServerInfo: mcp.Info{
+				Name:    s.Name,
+				Version: s.Version,
+			},
+			Capabilities: s.Capabilities(),
+		}, r.Request.Id))
+	case mcp.NotificationsInitializedRequest, mcp.NotificationsRootsListChangedRequest:
+		go func() {
+			if err := mcpconn.refreshRoots(ctx); err != nil {
+				mcpconn.Logger().Warn("refresh roots", slog.Any("err", err))
+			}
+		}()
+	case mcp.PingRequest:
+		senderr = send("message", rpc.NewResponse(struct{}{}, r.Id))
+	case mcp.Response:
+		mcpconn.resolve(rpc.Request(r))
+	case mcp.PromptsListRequest:
+		prompts, next, err := mcp.Paginate(s.ListPrompts(), r.Params.Cursor, s.pagesize)
+		if err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(mcp.PromptsListResponse{
+			Prompts:    prompts,
+			NextCursor: next,
+		}, r.Id))
+	case mcp.PromptsGetRequest:
+		res, err := s.GetPrompt(r.Params.Name, r.Params.Arguments)
+		switch {
+		case errors.Is(err, ErrNoPrompt), errors.Is(err, ErrPromptArgs):
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+		case err != nil:
+			slog.Error("get prompt", slog.String("name", r.Params.Name), slog.Any("err", err))
+			senderr = send("message", rpc.NewErrorResponse(rpc.InternalError, err.Error(), nil, r.Id))
+		default:
+			senderr = send("message", rpc.NewResponse(res, r.Id))
+		}
+	case mcp.ResourcesListRequest:
+		resources, next, err := mcp.Paginate(mcpconn.ListResources(), r.Params.Cursor, s.pagesize)
+		if err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(mcp.ResourcesListResponse{
+			Resources:  resources,
+			NextCursor: next,
+		}, r.Id))
+	case mcp.ResourcesTemplatesListRequest:
+		templates, next, err := mcp.Paginate(ResourceTemplates(), r.Params.Cursor, s.pagesize)
+		if err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(mcp.ResourcesTemplatesListResponse{
+			ResourceTemplates: templates,
+			NextCursor:        next,
+		}, r.Id))
+	case mcp.ResourcesReadRequest:
+		go func() {
+			// the response is sent after Handle returns, log its error.
+			var senderr error
+			defer func() {
+				if senderr != nil {
+					mcpconn.Logger().Error("send message", slog.Any("err", senderr))
+				}
+			}()
+
+			contents, err := mcpconn.ReadResource(r.Params.URI)
+			if errors.Is(err, ErrResourceNotFound) {
+				senderr = send("message", rpc.NewErrorResponse(
+					mcp.ResourceNotFound, "resource not found", map[string]string{"uri": r.Params.URI}, r.Id,
+				))
+				return
+			}
+			if err != nil {
+				mcpconn.Logger().Error("read resource", slog.String("uri", r.Params.URI), slog.Any("err", err))
+				senderr = send("message", rpc.NewErrorResponse(rpc.InternalError, err.Error(), nil, r.Id))
+				return
+			}
+
+			senderr = send("message", rpc.NewResponse(mcp.ResourcesReadResponse{
+				Contents: contents,
+			}, r.Id))
+		}()
+	case mcp.ResourcesSubscribeRequest:
+		mcpconn.Subscribe(r.Params.URI)
+		senderr = send("message", rpc.NewResponse(struct{}{}, r.Id))
+	case mcp.ResourcesUnsubscribeRequest:
+		mcpconn.Unsubscribe(r.Params.URI)
+		senderr = send("message", rpc.NewResponse(struct{}{}, r.Id))
+	case mcp.CompletionCompleteRequest:
+		values, err := mcpconn.Complete(r.Params.Ref, r.Params.Argument.Name, r.Params.Argument.Value)
+		if err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(mcp.CompletionCompleteResponse{
+			Completion: mcp.NewCompletion(values),
+		}, r.Id))
+	case mcp.LoggingSetLevelRequest:
+		if err := mcpconn.SetLogLevel(r.Params.Level); err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(struct{}{}, r.Id))
+	case mcp.ToolsListRequest:
+		tools, next, err := mcp.Paginate(mcpconn.ListTools(), r.Params.Cursor, s.pagesize)
+		if err != nil {
+			senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), nil, r.Id))
+			break
+		}
+		senderr = send("message", rpc.NewResponse(mcp.ToolsListResponse{
+			Tools:      tools,
+			NextCursor: next,
+		}, r.Id))
+	case mcp.ToolsCallRequest:
+		slog.Debug("call tool", slog.String("name", r.Params.Name), slog.Int("id", r.Id))
+
+		release, err := mcpconn.acquireCall()
+		if err != nil {
+			slog.Debug("call tool", slog.String("name", r.Params.Name), slog.Any("err", err))
+			senderr = send("message", rpc.NewErrorResponse(RateLimited, err.Error(), err, r.Id))
+			break
+		}
+
+		go func() {
+			// the response is sent after Handle returns, log its error.
+			var senderr error
+			defer func() {
+				if senderr != nil {
+					mcpconn.Logger().Error("send message", slog.Any("err", senderr))
+				}
+			}()
+
+			res, err := s.CallTool(ctx, mcpconn, r)
+			defer func() { release(resultSize(res)) }()
+
+			var rlerr *RateLimitError
+			if errors.As(err, &rlerr) {
+				senderr = send("message", rpc.NewErrorResponse(RateLimited, err.Error(), rlerr, r.Id))
+				return
+			}
+
+			var verr *mcp.ValidationError
+			if errors.As(err, &verr) {
+				slog.Debug("call tool", slog.String("name", r.Params.Name), slog.Any("err", err))
+				senderr = send("message", rpc.NewErrorResponse(rpc.InvalidParams, err.Error(), verr, r.Id))
+				return
+			}
+			if errors.Is(err, ErrNoTool) {
+				senderr = send("message", rpc.NewErrorResponse(
+					rpc.InvalidParams, fmt.Sprintf("unknown tool: %s", r.Params.Name), nil, r.Id,
+				))
+				return
+			}
+
+			if err != nil {
+				mcpconn.Logger().Error("call tool", slog.String("name", r.Params.Name), slog.Any("err", err))
+				res := text(err.Error())
+				res.IsError = true
+				senderr = send("message", rpc.NewResponse(res, r.Id))
+				return
+			}
+
+			senderr = send("message", rpc.NewResponse(res, r.Id))
+		}()
+
+	case mcp.NotificationsCancelledRequest:
+		slog.Debug("cancelled",
+			slog.Int("id", r.Params.RequestId),
+			slog.String("reason", r.Params.Reason),
+		)
+		// TODO cancel the corresponding request.
+	}
+
+	if senderr != nil {
+		return fmt.Errorf("send message: %w", senderr)
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/google/uuid"
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+var InvalidSessionId = errors.New("invalid session id")
+
+type SessionId uuid.UUID
+
+func (id SessionId) String() string {
+	return uuid.UUID(id).String()
+}
+
+func (id *SessionId) Set(v string) error {
+	if len(v) == 0 {
+		return InvalidSessionId
+	}
+
+	vv, err := uuid.Parse(v)
+	if err != nil {
+		return InvalidSessionId
+	}
+
+	*id = SessionId(vv)
+	return nil
+}
+
+type Sessions struct {
+	sync.Mutex
+	s map[SessionId]*Session
+}
+
+func NewSessions() *Sessions {
+	return &Sessions{
+		s: make(map[SessionId]*Session),
+	}
+}
+
+func (ss *Sessions) Add(s *Session) {
+	ss.Lock()
+	ss.s[s.id] = s
+	ss.Unlock()
+}
+
+func (ss *Sessions) Get(id SessionId) (*Session, bool) {
+	ss.Lock()
+	s, ok := ss.s[id]
+	ss.Unlock()
+	return s, ok
+}
+
+func (ss *Sessions) Remove(id SessionId) {
+	ss.Lock()
+	delete(ss.s, id)
+	ss.Unlock()
+}
+
+// DefaultSessionGrace is the default duration a SSE session outlives its
+// stream, waiting for the client to reconnect.
+const DefaultSessionGrace = time.Minute
+
+// sessionReplaySize and sessionReplayBytes bound the events kept for replay.
+const (
+	sessionReplaySize  = 256
+	sessionReplayBytes = 16 << 20
+)
+
+var ErrSessionReplay = errors.New("session events can't be replayed")
+
+type Session struct {
+	sync.Mutex
+	id        SessionId
+	creq      chan mcp.Request
+	createdAt time.Time
+	// principal is the name of the client owning the session.
+	principal string
+
+	// conn is the session's connection, it outlives the streams.
+	conn   *MCPConn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// events are the last events sent, replayed to the reconnecting clients.
+	// They are kept until the client acknowledges them by answering a ping.
+	events []sessionEvent
+	size   int
+	lastid uint64
+	// acked is the last event sent before the last heartbeat, the client
+	// received it once it answers the following ping.
+	acked uint64
+	// stream is the attached SSE stream, nil when the client is
+	// disconnected.
+	stream *stream
+	// grace is the duration the session waits a reconnection.
+	grace  time.Duration
+	expire *time.Timer
+}
+
+type sessionEvent struct {
+	id    uint64
+	event string
+	data  json.RawMessage
+}
+
+func NewSession() *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Session{
+		id:        SessionId(uuid.New()),
+		creq:      make(chan mcp.Request),
+		createdAt: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Close ends the session, the pending requests are dropped.
+func (s *Session) Close() {
+	s.cancel()
+}
+
+// Done returns a channel closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+func (s *Session) Requests() chan mcp.Request {
+	return s.creq
+}
+
+// eventId returns the SSE event id, it contains the session id to resume
+// the session on reconnection.
+func (s *Session) eventId(n uint64) string {
+	return fmt.Sprintf("%s/%d", s.id, n)
+}
+
+// parseEventId returns the session id and the event number of a
+// Last-Event-ID header.
+func parseEventId(v string) (SessionId, uint64, error) {
+	var id SessionId
+
+	sid, n, ok := strings.Cut(v, "/")
+	if !ok {
+		return id, 0, InvalidSessionId
+	}
+	if err := id.Set(sid); err != nil {
+		return id, 0, err
+	}
+	last, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		return id, 0, InvalidSessionId
+	}
+	return id, last, nil
+}
+
+// send keeps the event for replay and writes it to the attached stream.
+// It doesn't fail w/o stream, the client gets the event on reconnection.
+func (s *Session) send(event string, data any) error {
+	s.Lock()
+	defer s.Unlock()
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	s.lastid++
+	e := sessionEvent{id: s.lastid, event: event, data: b}
+
+	s.events = append(s.events, e)
+	s.size += len(b)
+	s.trim()
+
+	if s.stream != nil {
+		if err := s.stream.event(s.eventId(e.id), e.event, e.data); err != nil {
+			slog.Debug("sse write", slog.Any("id", s.id), slog.Any("err", err))
+			s.detachLocked(s.stream)
+		}
+	}
+
+	return nil
+}
+
+// attach writes the endpoint and the events following last to the stream,
+// the next events are written to it.
+// A previously attached stream is detached.
+func (s *Session) attach(st *stream, endpoint string, last uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.expire != nil {
+		if !s.expire.Stop() {
+			// the session is expiring.
+			return ErrSessionReplay
+		}
+		s.expire = nil
+	}
+	if s.ctx.Err() != nil {
+		return ErrSessionReplay
+	}
+
+	// the events following last must still be kept.
+	if last > s.lastid || (len(s.events) > 0 && s.events[0].id > last+1) {
+		return ErrSessionReplay
+	}
+	if len(s.events) == 0 && last < s.lastid {
+		return ErrSessionReplay
+	}
+
+	if s.stream != nil {
+		s.stream.cancel()
+	}
+	s.stream = st
+
+	// the acknowledged events are no longer needed.
+	s.drop(last)
+	s.acked = 0
+
+	if err := st.event("", "endpoint", endpoint); err != nil {
+		s.detachLocked(st)
+		return err
+	}
+	for _, e := range s.events {
+		if err := st.event(s.eventId(e.id), e.event, e.data); err != nil {
+			s.detachLocked(st)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trim drops the oldest events exceeding the replay bounds.
+func (s *Session) trim() {
+	i := 0
+	for n := len(s.events); i < n && (n-i > sessionReplaySize || s.size > sessionReplayBytes); i++ {
+		s.size -= len(s.events[i].data)
+	}
+	s.events = slices.Delete(s.events, 0, i)
+}
+
+// drop drops the events up to the id.
+func (s *Session) drop(id uint64) {
+	i, _ := slices.BinarySearchFunc(s.events, id+1, func(e sessionEvent, id uint64) int {
+		return cmp.Compare(e.id, id)
+	})
+	for _, e := range s.events[:i] {
+		s.size -= len(e.data)
+	}
+	s.events = slices.Delete(s.events, 0, i)
+}
+
+// detach detaches the stream if attached, the session is closed after the
+// grace period w/o reconnection.
+func (s *Session) detach(st *stream) {
+	s.Lock()
+	s.detachLocked(st)
+	s.Unlock()
+}
+
+func (s *Session) detachLocked(st *stream) {
+	if s.stream != st {
+		return
+	}
+	st.cancel()
+	s.stream = nil
+
+	if s.grace <= 0 {
+		s.cancel()
+		return
+	}
+	s.expire = time.AfterFunc(s.grace, s.cancel)
+}
+
+// heartbeat writes a comment to the stream if attached.
+// The heartbeats are sent before the pings and the next one only once the
+// ping is answered: the events sent before the previous heartbeat are
+// received and dropped.
+func (s *Session) heartbeat(st *stream) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.stream != st {
+		return errors.New("stream detached")
+	}
+	if err := st.comment("ping"); err != nil {
+		return err
+	}
+
+	s.drop(s.acked)
+	s.acked = s.lastid
+
+	return nil
+}
+
+// A stream is a SSE connection of a session.
+type stream struct {
+	w      io.Writer
+	f      http.Flusher
+	cancel context.CancelFunc
+}
+
+func (st *stream) event(id, event string, data any) error {
+	// the raw JSON is written as is.
+	if raw, ok := data.(json.RawMessage); ok {
+		data = string(raw)
+	}
+	err := sse.Encode(st.w, sse.Event{
+		Id:    id,
+		Event: event,
+		Data:  data,
+	})
+	if err != nil {
+		return fmt.Errorf("encode: %s", err)
+	}
+	st.f.Flush()
+	return nil
+}
+
+// comment writes a SSE comment, ignored by the clients.
+func (st *stream) comment(c string) error {
+	if _, err := io.WriteString(st.w, ": "+c+"\n\n"); err != nil {
+		return err
+	}
+	st.f.Flush()
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flushBuffer is a stream writer recording the events.
+type flushBuffer struct {
+	bytes.Buffer
+}
+
+func (*flushBuffer) Flush() {}
+
+func newTestStream() (*stream, *flushBuffer, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := &flushBuffer{}
+	return &stream{w: buf, f: buf, cancel: cancel}, buf, ctx
+}
+
+// eventIds returns the ids of the events written.
+func eventIds(buf *flushBuffer) []string {
+	var ids []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if id, ok := strings.CutPrefix(line, "id:"); ok {
+			_, n, _ := strings.Cut(id, "/")
+			ids = append(ids, n)
+		}
+	}
+	return ids
+}
+
+func TestSessionReplay(t *testing.T) {
+	s := NewSession()
+	s.grace = time.Minute
+	defer s.Close()
+
+	st1, buf1, ctx1 := newTestStream()
+	if err := s.attach(st1, "messages", 0); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if !strings.HasPrefix(buf1.String(), "event:endpoint\ndata:messages\n\n") {
+		t.Errorf("endpoint: %q", buf1.String())
+	}
+
+	for i := range 2 {
+		s.send("message", i)
+	}
+	if got := fmt.Sprint(eventIds(buf1)); got != "[1 2]" {
+		t.Errorf("ids: %s", got)
+	}
+
+	s.detach(st1)
+	if ctx1.Err() == nil {
+		t.Error("detached stream not cancelled")
+	}
+
+	// the events are kept while disconnected.
+	for i := range 2 {
+		s.send("message", i+2)
+	}
+
+	st2, buf2, _ := newTestStream()
+	if err := s.attach(st2, "messages", 1); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if got := fmt.Sprint(eventIds(buf2)); got != "[2 3 4]" {
+		t.Errorf("replayed ids: %s", got)
+	}
+	if !strings.Contains(buf2.String(), "id:"+s.id.String()+"/4\nevent:message\ndata:3\n\n") {
+		t.Errorf("event: %q", buf2.String())
+	}
+
+	// a new stream takes over.
+	st3, buf3, _ := newTestStream()
+	if err := s.attach(st3, "messages", 4); err != nil {
+		t.Fatalf("take over: %v", err)
+	}
+	if ids := eventIds(buf3); len(ids) != 0 {
+		t.Errorf("replayed acknowledged events: %v", ids)
+	}
+	s.send("message", 4)
+	if got := fmt.Sprint(eventIds(buf2)); got != "[2 3 4]" {
+		t.Errorf("detached stream written: %s", got)
+	}
+	if got := fmt.Sprint(eventIds(buf3)); got != "[5]" {
+		t.Errorf("ids: %s", got)
+	}
+}
+
+func TestSessionAttachErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sent int
+		last uint64
+	}{
+		{name: "future event", sent: 2, last: 3},
+		{name: "trimmed events", sent: sessionReplaySize + 2, last: 1},
+		{name: "no events", sent: 0, last: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSession()
+			s.grace = time.Minute
+			defer s.Close()
+
+			for i := range tc.sent {
+				s.send("message", i)
+			}
+
+			st, buf, _ := newTestStream()
+			if err := s.attach(st, "messages", tc.last); !errors.Is(err, ErrSessionReplay) {
+				t.Errorf("got %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("written: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestSessionBounds(t *testing.T) {
+	s := NewSession()
+	defer s.Close()
+
+	big := strings.Repeat("x", sessionReplayBytes/4)
+	for range 8 {
+		s.send("message", big)
+	}
+	if s.size > sessionReplayBytes {
+		t.Errorf("size: %d", s.size)
+	}
+	if len(s.events) != 3 {
+		t.Errorf("events: %d", len(s.events))
+	}
+
+	for i := range 2 * sessionReplaySize {
+		s.send("message", i)
+	}
+	if len(s.events) != sessionReplaySize {
+		t.Errorf("events: %d", len(s.events))
+	}
+}
+
+func TestSessionHeartbeat(t *testing.T) {
+	s := NewSession()
+	s.grace = time.Minute
+	defer s.Close()
+
+	st, buf, _ := newTestStream()
+	if err := s.attach(st, "messages", 0); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+
+	s.send("message", 1)
+	s.send("message", 2)
+
+	// the events are received once the following ping is answered.
+	if err := s.heartbeat(st); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if len(s.events) != 2 {
+		t.Errorf("events dropped before the ping answer: %d", len(s.events))
+	}
+	s.send("message", 3)
+
+	if err := s.heartbeat(st); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if len(s.events) != 1 || s.events[0].id != 3 || s.size != 1 {
+		t.Errorf("events: %+v, size %d", s.events, s.size)
+	}
+	if !strings.Contains(buf.String(), ": ping\n\n") {
+		t.Errorf("no comment: %q", buf.String())
+	}
+
+	s.detach(st)
+	if err := s.heartbeat(st); err == nil {
+		t.Error("heartbeat of a detached stream")
+	}
+}
+
+func TestSessionGrace(t *testing.T) {
+	s := NewSession()
+	st, _, _ := newTestStream()
+	if err := s.attach(st, "messages", 0); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	s.detach(st)
+	select {
+	case <-s.Done():
+	default:
+		t.Error("session w/o grace not closed")
+	}
+
+	s = NewSession()
+	s.grace = 10 * time.Millisecond
+	st, _, _ = newTestStream()
+	if err := s.attach(st, "messages", 0); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	s.detach(st)
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Error("session not closed after the grace period")
+	}
+
+	st, _, _ = newTestStream()
+	if err := s.attach(st, "messages", 0); !errors.Is(err, ErrSessionReplay) {
+		t.Errorf("attach to a closed session: %v", err)
+	}
+}
+
+func TestParseEventId(t *testing.T) {
+	s := NewSession()
+	id, n, err := parseEventId(s.eventId(42))
+	if err != nil || id != s.id || n != 42 {
+		t.Errorf("got %s %d %v", id, n, err)
+	}
+
+	for _, v := range []string{"", "42", s.id.String(), s.id.String() + "/x", "x/1"} {
+		if _, _, err := parseEventId(v); err == nil {
+			t.Errorf("%q: no error", v)
+		}
+	}
+}
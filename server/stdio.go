@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package server
 
 import (
 	"bufio"
@@ -26,8 +26,10 @@ import (
 	"github.com/lightpanda-io/gomcp/mcp"
 )
 
-func runstd(ctx context.Context, in io.Reader, out io.Writer, mcpsrv *MCPServer) error {
-
+// ServeStdio serves a single client using newline delimited JSON messages
+// read from in and written to out.
+// It returns when in is closed or ctx is cancelled.
+func (s *MCPServer) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -36,18 +38,25 @@ func runstd(ctx context.Context, in io.Reader, out io.Writer, mcpsrv *MCPServer)
 
 	enc := json.NewEncoder(out)
 
+	send := func(event string, data any) error {
+		if err := enc.Encode(data); err != nil {
+			return fmt.Errorf("encode: %s", err)
+		}
+		return nil
+	}
+
 	// create the mcpconn
-	mcpconn := mcpsrv.NewConn()
+	mcpconn := s.NewConn(send)
 	defer mcpconn.Close()
 
 	go func() {
-		send := func(event string, data any) error {
-			if err := enc.Encode(data); err != nil {
-				return fmt.Errorf("encode: %s", err)
-			}
-			return nil
+		if err := mcpconn.keepAlive(ctx, s.stdiokeepalive, nil); err != nil {
+			slog.Debug("keepalive", slog.Any("err", err))
+			cancel()
 		}
+	}()
 
+	go func() {
 		for {
 			select {
 			case <-ctx.Done():
@@ -57,7 +66,7 @@ func runstd(ctx context.Context, in io.Reader, out io.Writer, mcpsrv *MCPServer)
 					// closed channel
 					return
 				}
-				if err := mcpsrv.Handle(ctx, rreq, mcpconn, send); err != nil {
+				if err := s.Handle(ctx, rreq, mcpconn, mcpconn.Send); err != nil {
 					// disconnect on error
 					slog.Error("handle req", slog.Any("err", err))
 					return
@@ -91,7 +100,7 @@ func runstd(ctx context.Context, in io.Reader, out io.Writer, mcpsrv *MCPServer)
 			close(cout)
 			return nil
 		case b := <-cin:
-			mcpreq, err := mcpsrv.Decode(bytes.NewReader(b))
+			mcpreq, err := s.Decode(bytes.NewReader(b))
 			if err != nil {
 				slog.Error("message decode error", slog.Any("err", err))
 				// TODO return an error
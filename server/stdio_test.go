@@ -0,0 +1,85 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStdioKeepAlive(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		ping bool
+	}{
+		{name: "default", opts: []Option{WithKeepAlive(10 * time.Millisecond)}},
+		{name: "enabled", opts: []Option{WithStdioKeepAlive(10 * time.Millisecond)}, ping: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := New(context.Background(), tc.opts...)
+
+			in, w := io.Pipe()
+			defer w.Close()
+			out := &syncBuffer{}
+
+			done := make(chan error, 1)
+			go func() {
+				done <- srv.ServeStdio(context.Background(), in, out)
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+
+			if got := strings.Contains(out.String(), `"method":"ping"`); got != tc.ping {
+				t.Errorf("ping sent: got %v, want %v: %s", got, tc.ping, out)
+			}
+
+			// the client not answering the pings is disconnected.
+			select {
+			case err := <-done:
+				if !tc.ping {
+					t.Errorf("server stopped: %v", err)
+				}
+			case <-time.After(time.Second):
+				if tc.ping {
+					t.Error("server not stopped")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,554 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lightpanda-io/gomcp/mcp"
+)
+
+// ToolHandler is a tool exposed to the clients.
+type ToolHandler interface {
+	// Tool returns the tool definition.
+	Tool() mcp.Tool
+	// Call runs the tool for the connection.
+	// The arguments are already validated against the tool's input schema.
+	Call(ctx context.Context, conn *MCPConn, args json.RawMessage) (mcp.ToolsCallResponse, error)
+}
+
+// ToolFunc adapts a function to a ToolHandler.
+type ToolFunc struct {
+	Definition mcp.Tool
+	Fn         func(ctx context.Context, conn *MCPConn, args json.RawMessage) (mcp.ToolsCallResponse, error)
+}
+
+func (t ToolFunc) Tool() mcp.Tool {
+	return t.Definition
+}
+
+func (t ToolFunc) Call(ctx context.Context, conn *MCPConn, args json.RawMessage) (mcp.ToolsCallResponse, error) {
+	return t.Fn(ctx, conn, args)
+}
+
+// AvailableTool is implemented by the tools available only in some
+// connection states, like when a page is loaded.
+// Available is checked each time the tools are listed or called.
+type AvailableTool interface {
+	ToolHandler
+	Available(conn *MCPConn) bool
+}
+
+// available returns true if the tool is available for the connection and
+// allowed for its principal.
+func available(h ToolHandler, conn *MCPConn) bool {
+	if o := conn.srv.oauth; o != nil && !o.Allows(conn.principal, h.Tool().Name) {
+		return false
+	}
+
+	a, ok := h.(AvailableTool)
+	return !ok || a.Available(conn)
+}
+
+// pageTool is embedded by the tools requiring a loaded page.
+type pageTool struct{}
+
+func (pageTool) Available(conn *MCPConn) bool {
+	return conn.tabID() != ""
+}
+
+// builtinTools returns the tools registered by default.
+func builtinTools(search SearchProvider) []ToolHandler {
+	return []ToolHandler{
+		gotoTool{},
+		searchTool{provider: search},
+		markdownTool{},
+		linksTool{},
+		pageInfoTool{},
+		formsTool{},
+		submitFormTool{},
+		screenshotTool{},
+		overTool{},
+	}
+}
+
+type gotoTool struct{}
+
+func (gotoTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name: "goto",
+		Description: "Navigate to a specified URL and load the page in" +
+			"memory so it can be reused later for info extraction.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"url": mcp.NewSchemaString("The URL to navigate to, must be a valid URL.").WithFormat("uri"),
+		}).WithRequired("url"),
+		Annotations: mcp.NewToolAnnotations("Navigate to URL").
+			WithReadOnly(false).WithDestructive(false).WithIdempotent(true).WithOpenWorld(true),
+	}
+}
+
+func (gotoTool) Call(_ context.Context, conn *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		URL string `json:"url"`
+	}
+
+	if err := json.Unmarshal(v, &args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+
+	res, err := conn.Goto(args.URL)
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+	return text(res), nil
+}
+
+// searchDefaultLimit is the number of search results returned by default.
+const searchDefaultLimit = 10
+
+type searchTool struct {
+	provider SearchProvider
+}
+
+func (searchTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "search",
+		Description: "Use a search engine to look for specific words, terms, sentences. Returns the ranked list of results with their title, URL and snippet. The search page will then be loaded in memory.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"text": mcp.NewSchemaString("The text to search for, must be a valid search query.").WithMinLength(1),
+			"limit": mcp.NewSchemaInteger("The maximum number of results to return.").
+				WithMinimum(1).WithMaximum(50).WithDefault(searchDefaultLimit),
+			"site":   mcp.NewSchemaString("Restrict the results to a domain, like example.com.").WithFormat("hostname"),
+			"page":   mcp.NewSchemaInteger("The search engine results page number, starting at 1. The page size depends on the search engine, not on the limit.").WithMinimum(1).WithDefault(1),
+			"region": mcp.NewSchemaString("The language-country code of the results region, like en-US.").WithPattern(`^[A-Za-z]{2}(-[A-Za-z]{2})?$`),
+		}).WithRequired("text"),
+		OutputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"query": mcp.NewSchemaObject(mcp.Properties{
+				"text":   mcp.NewSchemaString("The searched text."),
+				"site":   mcp.NewSchemaString("The domain filter."),
+				"page":   mcp.NewSchemaInteger("The results page number."),
+				"region": mcp.NewSchemaString("The results region."),
+			}).WithRequired("text"),
+			"results": mcp.NewSchemaArray("The search results.", mcp.NewSchemaObject(mcp.Properties{
+				"rank":    mcp.NewSchemaInteger("The result position in the results page, starting at 1 on each page."),
+				"title":   mcp.NewSchemaString("The result title."),
+				"url":     mcp.NewSchemaString("The result URL.").WithFormat("uri"),
+				"snippet": mcp.NewSchemaString("The result description."),
+			}).WithRequired("rank", "title", "url")),
+		}).WithRequired("query", "results"),
+		Annotations: mcp.NewToolAnnotations("Web search").
+			WithReadOnly(false).WithDestructive(false).WithIdempotent(true).WithOpenWorld(true),
+	}
+}
+
+func (t searchTool) Call(_ context.Context, conn *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		SearchQuery
+		Limit int `json:"limit"`
+	}
+
+	if err := json.Unmarshal(v, &args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+	if args.Limit <= 0 {
+		args.Limit = searchDefaultLimit
+	}
+
+	res, err := conn.Search(t.provider, args.SearchQuery)
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+	res = rankSearchResults(res, args.Limit)
+
+	return structured(formatSearchResults(res), struct {
+		Query   SearchQuery    `json:"query"`
+		Results []SearchResult `json:"results"`
+	}{args.SearchQuery, res})
+}
+
+type markdownTool struct{ pageTool }
+
+func (markdownTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name: "markdown",
+		Description: "Get the page content in markdown format. " +
+			"Long pages can be summarized by the client's model if it supports sampling.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"summarize":   mcp.NewSchemaBoolean("Return a summary of the content instead of the full content.").WithDefault(false),
+			"instruction": mcp.NewSchemaString("The summary instruction, like the information to keep. Requires summarize."),
+		}),
+		Annotations: mcp.NewToolAnnotations("Page content as markdown").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (markdownTool) Call(ctx context.Context, conn *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		Summarize   bool   `json:"summarize"`
+		Instruction string `json:"instruction"`
+	}
+
+	if err := json.Unmarshal(v, &args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+
+	res, err := conn.GetMarkdown()
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+
+	if args.Summarize {
+		res, err = conn.Summarize(ctx, res, args.Instruction)
+		if err != nil {
+			return mcp.ToolsCallResponse{}, err
+		}
+	}
+
+	return text(res), nil
+}
+
+type linksTool struct{ pageTool }
+
+func (linksTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "links",
+		Description: "Extract all links in the opened page",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{}),
+		OutputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"links": mcp.NewSchemaArray("The page links.", mcp.NewSchemaObject(mcp.Properties{
+				"url":  mcp.NewSchemaString("The link href."),
+				"text": mcp.NewSchemaString("The link text."),
+			}).WithRequired("url")),
+		}).WithRequired("links"),
+		Annotations: mcp.NewToolAnnotations("Page links").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (linksTool) Call(_ context.Context, conn *MCPConn, _ json.RawMessage) (mcp.ToolsCallResponse, error) {
+	links, err := conn.GetLinks()
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+
+	hrefs := make([]string, 0, len(links))
+	for _, l := range links {
+		hrefs = append(hrefs, l.URL)
+	}
+
+	return structured(strings.Join(hrefs, "\n"), struct {
+		Links []Link `json:"links"`
+	}{links})
+}
+
+type pageInfoTool struct{ pageTool }
+
+func (pageInfoTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "page_info",
+		Description: "Get general information about the opened page: URL, title, description, language and counts of links and forms.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{}),
+		OutputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"url":         mcp.NewSchemaString("The page URL.").WithFormat("uri"),
+			"title":       mcp.NewSchemaString("The page title."),
+			"description": mcp.NewSchemaString("The page meta description."),
+			"language":    mcp.NewSchemaString("The page language."),
+			"canonical":   mcp.NewSchemaString("The page canonical URL."),
+			"links":       mcp.NewSchemaInteger("The number of links."),
+			"forms":       mcp.NewSchemaInteger("The number of forms."),
+		}).WithRequired("url", "title", "links", "forms"),
+		Annotations: mcp.NewToolAnnotations("Page information").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (pageInfoTool) Call(_ context.Context, conn *MCPConn, _ json.RawMessage) (mcp.ToolsCallResponse, error) {
+	info, err := conn.GetPageInfo()
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+	return structured(info.String(), info)
+}
+
+type formsTool struct{ pageTool }
+
+func (formsTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "forms",
+		Description: "Extract the forms of the opened page with their fields.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{}),
+		OutputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"forms": mcp.NewSchemaArray("The page forms.", mcp.NewSchemaObject(mcp.Properties{
+				"id":     mcp.NewSchemaString("The form id."),
+				"name":   mcp.NewSchemaString("The form name."),
+				"action": mcp.NewSchemaString("The form action URL."),
+				"method": mcp.NewSchemaString("The form method, uppercased."),
+				"fields": mcp.NewSchemaArray("The form fields.", mcp.NewSchemaObject(mcp.Properties{
+					"tag": mcp.NewSchemaString("The field element.").
+						WithEnum("input", "select", "textarea", "button"),
+					"type":        mcp.NewSchemaString("The field type attribute."),
+					"name":        mcp.NewSchemaString("The field name."),
+					"id":          mcp.NewSchemaString("The field id."),
+					"label":       mcp.NewSchemaString("The field label."),
+					"placeholder": mcp.NewSchemaString("The field placeholder."),
+					"value":       mcp.NewSchemaString("The field value."),
+					"required":    mcp.NewSchemaBoolean("Whether the field is required."),
+					"options":     mcp.NewSchemaArray("The select options.", mcp.NewSchemaString("An option text.")),
+				}).WithRequired("tag", "required")),
+			}).WithRequired("method", "fields")),
+		}).WithRequired("forms"),
+		Annotations: mcp.NewToolAnnotations("Page forms").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (formsTool) Call(_ context.Context, conn *MCPConn, _ json.RawMessage) (mcp.ToolsCallResponse, error) {
+	forms, err := conn.GetForms()
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+
+	return structured(formatForms(forms), struct {
+		Forms []Form `json:"forms"`
+	}{forms})
+}
+
+type submitFormTool struct{ pageTool }
+
+func (submitFormTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name: "submit_form",
+		Description: "Fill and submit a form of the opened page. " +
+			"The user is asked for the missing required fields and to confirm the submission " +
+			"if the client supports it, otherwise all the required values must be given. " +
+			"The passwords are never asked to the user and must be given in the values.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"form": mcp.NewSchemaInteger("The form number in the forms tool order, starting at 1.").
+				WithMinimum(1).WithDefault(1),
+			"values": mcp.NewSchemaObject(nil).WithAdditionalProperties(true).
+				WithDescription("The field values by field name, as strings, numbers or booleans for the checkboxes."),
+		}),
+		Annotations: mcp.NewToolAnnotations("Submit form").
+			WithReadOnly(false).WithDestructive(true).WithIdempotent(false).WithOpenWorld(true),
+	}
+}
+
+func (submitFormTool) Call(ctx context.Context, conn *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		Form   int            `json:"form"`
+		Values map[string]any `json:"values"`
+	}
+
+	// keep the numbers as written.
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.UseNumber()
+	if err := dec.Decode(&args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+	if args.Form == 0 {
+		args.Form = 1
+	}
+
+	values := make(map[string]string, len(args.Values))
+	for k, v := range args.Values {
+		s, err := formValue(v)
+		if err != nil {
+			return mcp.ToolsCallResponse{}, fmt.Errorf("value %s: %w", k, err)
+		}
+		values[k] = s
+	}
+
+	forms, err := conn.GetForms()
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+	if args.Form > len(forms) {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("form %d not found, the page has %d forms", args.Form, len(forms))
+	}
+	form := forms[args.Form-1]
+
+	// the elicitation must not request sensitive information.
+	if passwords := form.passwords(values); len(passwords) > 0 {
+		names := make([]string, 0, len(passwords))
+		for _, f := range passwords {
+			names = append(names, f.Name)
+		}
+		return mcp.ToolsCallResponse{}, fmt.Errorf("missing values for the password fields: %s, they can't be requested from the user by this server and must be given in values", strings.Join(names, ", "))
+	}
+
+	// ask the user for the missing values.
+	if missing := form.missing(values); len(missing) > 0 {
+		props := make(mcp.Properties, len(missing))
+		names := make([]string, 0, len(missing))
+		for _, f := range missing {
+			desc := f.Label
+			if desc == "" {
+				desc = f.Placeholder
+			}
+			props[f.Name] = mcp.NewSchemaString(desc)
+			if f.Type == "email" {
+				props[f.Name].WithFormat("email")
+			}
+			names = append(names, f.Name)
+		}
+
+		content, err := conn.Elicit(ctx,
+			fmt.Sprintf("The form %s requires the following values.", form.title()),
+			mcp.NewSchemaObject(props).WithRequired(names...),
+		)
+		if errors.Is(err, ErrNoElicitation) {
+			return mcp.ToolsCallResponse{}, fmt.Errorf("missing values for the fields: %s", strings.Join(names, ", "))
+		}
+		if err != nil {
+			return mcp.ToolsCallResponse{}, err
+		}
+		for k, v := range content {
+			s, err := formValue(v)
+			if err != nil {
+				return mcp.ToolsCallResponse{}, fmt.Errorf("value %s: %w", k, err)
+			}
+			values[k] = s
+		}
+	}
+
+	ok, err := conn.Confirm(ctx, fmt.Sprintf("Submit the form %s?", form.title()))
+	switch {
+	case errors.Is(err, ErrNoElicitation):
+		// the tool is annotated as destructive, the client is responsible
+		// for the approval.
+	case err != nil:
+		return mcp.ToolsCallResponse{}, err
+	case !ok:
+		return text("The user declined the form submission."), nil
+	}
+
+	res, err := conn.SubmitForm(args.Form-1, values)
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+	return text(res), nil
+}
+
+// formValue returns the string value of a form field.
+// The booleans check the checkboxes, null empties the field.
+func formValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
+
+type screenshotTool struct{ pageTool }
+
+func (screenshotTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name: "screenshot",
+		Description: "Capture a PNG screenshot of the opened page. The screenshot is also available as a resource. " +
+			"Requires a browser able to render pages, Lightpanda browser doesn't support it.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"full_page": mcp.NewSchemaBoolean("Capture the full page instead of the viewport.").WithDefault(false),
+		}),
+		Annotations: mcp.NewToolAnnotations("Page screenshot").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (screenshotTool) Call(_ context.Context, conn *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		FullPage bool `json:"full_page"`
+	}
+
+	if err := json.Unmarshal(v, &args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+
+	img, uri, err := conn.Screenshot(args.FullPage)
+	if err != nil {
+		return mcp.ToolsCallResponse{}, err
+	}
+
+	return mcp.ToolsCallResponse{
+		Content: []mcp.ToolsCallContent{
+			mcp.NewImageContent(img, "image/png"),
+			mcp.NewTextContent(fmt.Sprintf("The screenshot is available as the resource %s.", uri)),
+		},
+	}, nil
+}
+
+type overTool struct{}
+
+func (overTool) Tool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "over",
+		Description: "Used to indicate that the task is over and give the final answer if there is any. This is the last tool to be called in a task.",
+		InputSchema: mcp.NewSchemaObject(mcp.Properties{
+			"result": mcp.NewSchemaString("The final result of the task."),
+		}),
+		Annotations: mcp.NewToolAnnotations("Task over").
+			WithReadOnly(true).WithOpenWorld(false),
+	}
+}
+
+func (overTool) Call(_ context.Context, _ *MCPConn, v json.RawMessage) (mcp.ToolsCallResponse, error) {
+	var args struct {
+		Text string `json:"result"`
+	}
+
+	if err := json.Unmarshal(v, &args); err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("args decode: %w", err)
+	}
+
+	return text(args.Text), nil
+}
+
+// text returns a response with a single text content.
+func text(s string) mcp.ToolsCallResponse {
+	return mcp.ToolsCallResponse{
+		Content: []mcp.ToolsCallContent{mcp.NewTextContent(s)},
+	}
+}
+
+// structured returns a response with v as structured content.
+// The content contains the readable text and the JSON serialization of v for
+// the clients w/o structured content support.
+func structured(readable string, v any) (mcp.ToolsCallResponse, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return mcp.ToolsCallResponse{}, fmt.Errorf("json encode: %w", err)
+	}
+
+	return mcp.ToolsCallResponse{
+		Content: []mcp.ToolsCallContent{
+			mcp.NewTextContent(readable),
+			mcp.NewTextContent(string(b)),
+		},
+		StructuredContent: v,
+	}, nil
+}
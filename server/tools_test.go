@@ -0,0 +1,65 @@
+// Copyright 2025 Lightpanda (Selecy SAS)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestFormValue(t *testing.T) {
+	var values map[string]any
+	dec := json.NewDecoder(bytes.NewReader([]byte(`{
+		"name": "Alice",
+		"age": 42,
+		"amount": 1000000,
+		"ratio": 0.5,
+		"subscribe": true,
+		"terms": false,
+		"comment": null,
+		"tags": ["a"],
+		"address": {"city": "Paris"}
+	}`)))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, want := range map[string]string{
+		"name":      "Alice",
+		"age":       "42",
+		"amount":    "1000000",
+		"ratio":     "0.5",
+		"subscribe": "true",
+		"terms":     "false",
+		"comment":   "",
+	} {
+		if got, err := formValue(values[k]); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", k, got, err, want)
+		}
+	}
+
+	for _, k := range []string{"tags", "address"} {
+		if _, err := formValue(values[k]); err == nil {
+			t.Errorf("%s: no error", k)
+		}
+	}
+
+	// the elicited numbers aren't decoded as json.Number.
+	if got, err := formValue(1e6); err != nil || got != "1000000" {
+		t.Errorf("float: got %q, %v", got, err)
+	}
+}